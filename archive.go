@@ -0,0 +1,62 @@
+package rem
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"errors"
+	"io"
+	"io/fs"
+)
+
+// NewFileFromZip returns a File that reads the member named name of zr. The File uses the io.ReaderAt backend if the
+// member is stored uncompressed, in this case the checksum is not verified, and the io.Reader backend otherwise, see
+// NewFileFromReader for memLimit, diskLimit and tempDir. The offsets are relative to the start of the member. The
+// File implements Namer with name as the name. The member is closed when the File and all its forks are closed.
+func NewFileFromZip(zr *zip.Reader, name string, memLimit, diskLimit int64, tempDir string, opts ...Option) (File, error) {
+	for _, zf := range zr.File {
+		if zf.Name != name {
+			continue
+		}
+		if zf.FileInfo().IsDir() {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: errors.New("is a directory")}
+		}
+		if zf.Method == zip.Store {
+			raw, err := zf.OpenRaw()
+			if err != nil {
+				return nil, err
+			}
+			if ra, ok := raw.(io.ReaderAt); ok {
+				return &namedFile{File: withContext(newOptions(opts).ctx, newReaderAt(ra)), name: name}, nil
+			}
+		}
+		rc, err := zf.Open()
+		if err != nil {
+			return nil, err
+		}
+		f := NewFileFromReader(struct{ io.Reader }{rc}, memLimit, diskLimit, tempDir, opts...)
+		return &namedFile{File: newClosingFile(f, rc), name: name}, nil
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// NewFileFromTar reads the tar archive r until the member named name, and returns a File that reads the member with
+// the io.Reader backend, see NewFileFromReader for memLimit, diskLimit and tempDir. The offsets are relative to the
+// start of the member. The File implements Namer with name as the name. r must not be read while the File is used.
+func NewFileFromTar(r io.Reader, name string, memLimit, diskLimit int64, tempDir string, opts ...Option) (File, error) {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		} else if err != nil {
+			return nil, err
+		}
+		if hdr.Name != name {
+			continue
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: errors.New("not a regular file")}
+		}
+		return &namedFile{File: NewFileFromReader(tr, memLimit, diskLimit, tempDir, opts...), name: name}, nil
+	}
+}
@@ -0,0 +1,108 @@
+package rem
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+)
+
+// TestNewFileFromZip tests the backends and the offsets of the zip members.
+func TestNewFileFromZip(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, m := range []struct {
+		name   string
+		method uint16
+		data   string
+	}{{"a.txt", zip.Store, "abç"}, {"b.txt", zip.Deflate, "def"}} {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: m.name, Method: m.method})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(m.data))
+	}
+	zw.Create("dir/")
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, data string
+		backend    File
+	}{{"a.txt", "abç", &readerAt{}}, {"b.txt", "def", &closingFile{}}}
+	for _, test := range tests {
+		f, err := NewFileFromZip(zr, test.name, 16, 0, "")
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", test.name, err)
+		}
+		if backend := f.(*namedFile).File; fmt.Sprintf("%T", backend) != fmt.Sprintf("%T", test.backend) {
+			t.Errorf("%s: expected a %T, got %T", test.name, test.backend, backend)
+		}
+		if got := readString(f); got != test.data {
+			t.Errorf("%s: unexpected content %q", test.name, got)
+		}
+		if offset := f.Offset(); offset != int64(len(test.data)) {
+			t.Errorf("%s: unexpected offset %d", test.name, offset)
+		}
+		if name := Name(f); name != test.name {
+			t.Errorf("unexpected name %q", name)
+		}
+		if err := f.Close(); err != nil {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+		}
+	}
+
+	if _, err := NewFileFromZip(zr, "c.txt", 16, 0, ""); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected %v, got %v", fs.ErrNotExist, err)
+	}
+	if _, err := NewFileFromZip(zr, "dir/", 16, 0, ""); err == nil {
+		t.Errorf("error expected")
+	}
+}
+
+// TestNewFileFromTar tests the reading and the offsets of a tar member.
+func TestNewFileFromTar(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, m := range []struct{ name, data string }{{"a.txt", "abc"}, {"b.txt", "deç"}} {
+		tw.WriteHeader(&tar.Header{Name: m.name, Mode: 0o600, Size: int64(len(m.data)), Typeflag: tar.TypeReg})
+		tw.Write([]byte(m.data))
+	}
+	tw.WriteHeader(&tar.Header{Name: "dir/", Mode: 0o700, Typeflag: tar.TypeDir})
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := NewFileFromTar(bytes.NewReader(buf.Bytes()), "b.txt", 16, 0, "")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	defer f.Close()
+	if got := readString(f); got != "deç" {
+		t.Errorf("unexpected content %q", got)
+	}
+	if offset := f.Offset(); offset != 4 {
+		t.Errorf("unexpected offset %d", offset)
+	}
+	if r, _ := f.Previous(); r != 'ç' {
+		t.Errorf("expected 'ç', got %q", r)
+	}
+
+	if _, err := NewFileFromTar(bytes.NewReader(buf.Bytes()), "c.txt", 16, 0, ""); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected %v, got %v", fs.ErrNotExist, err)
+	}
+	if _, err := NewFileFromTar(bytes.NewReader(buf.Bytes()), "dir/", 16, 0, ""); err == nil {
+		t.Errorf("error expected")
+	}
+	if _, err := NewFileFromTar(bytes.NewReader([]byte("invalid")), "a.txt", 16, 0, ""); err == nil {
+		t.Errorf("error expected")
+	}
+}
@@ -0,0 +1,78 @@
+package rem
+
+import (
+	"io"
+	"sync"
+)
+
+// blockCache is a io.ReaderAt that reads another io.ReaderAt in blocks, and keeps the blocks used most recently in
+// memory. It is safe for concurrent use.
+type blockCache struct {
+	mu sync.Mutex
+	// ra is the input.
+	ra io.ReaderAt
+	// blockSize is the size of the blocks.
+	blockSize int64
+	// maxBlocks is the maximum number of blocks in memory.
+	maxBlocks int
+	// blocks are the blocks in memory by index. A block is shorter than blockSize only at the end of the input.
+	blocks map[int64][]byte
+	// recent are the indexes of the blocks in memory, the most recently used last.
+	recent []int64
+}
+
+// newBlockCache creates a new blockCache.
+func newBlockCache(ra io.ReaderAt, blockSize int64, maxBlocks int) *blockCache {
+	return &blockCache{ra: ra, blockSize: blockSize, maxBlocks: maxBlocks, blocks: make(map[int64][]byte)}
+}
+
+// ReadAt implements io.ReaderAt.
+func (bc *blockCache) ReadAt(p []byte, off int64) (n int, err error) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	for n < len(p) {
+		pos := off + int64(n)
+		b := pos / bc.blockSize
+		data, err := bc.block(b)
+		if err != nil {
+			return n, err
+		}
+		i := pos - b*bc.blockSize
+		if i >= int64(len(data)) {
+			return n, io.EOF
+		}
+		n += copy(p[n:], data[i:])
+	}
+	return n, nil
+}
+
+// block returns the block b, reading it if it is not in memory.
+func (bc *blockCache) block(b int64) ([]byte, error) {
+	if data, ok := bc.blocks[b]; ok {
+		bc.use(b)
+		return data, nil
+	}
+	data := make([]byte, bc.blockSize)
+	n, err := bc.ra.ReadAt(data, b*bc.blockSize)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if len(bc.recent) == bc.maxBlocks {
+		delete(bc.blocks, bc.recent[0])
+		bc.recent = bc.recent[1:]
+	}
+	bc.blocks[b] = data[:n]
+	bc.recent = append(bc.recent, b)
+	return data[:n], nil
+}
+
+// use marks the block b as the most recently used.
+func (bc *blockCache) use(b int64) {
+	for i, r := range bc.recent {
+		if r == b {
+			copy(bc.recent[i:], bc.recent[i+1:])
+			bc.recent[len(bc.recent)-1] = b
+			return
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package rem
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+// closingFile is a File that closes other resources after the File is closed. The forks share the resources, they are
+// closed when the last of the forks is closed.
+type closingFile struct {
+	File
+	// c are the resources.
+	c *closers
+	// closed indicates whether Close was called.
+	closed bool
+}
+
+// closers are resources shared by the forks of a closingFile.
+type closers struct {
+	mu sync.Mutex
+	// list are closed in order.
+	list []io.Closer
+	// refs is the number of forks that are not closed.
+	refs int
+}
+
+// newClosingFile creates a new closingFile that closes cs after f.
+func newClosingFile(f File, cs ...io.Closer) *closingFile {
+	return &closingFile{File: f, c: &closers{list: cs, refs: 1}}
+}
+
+// Fork returns a fork of the File that shares the resources.
+func (cf *closingFile) Fork() File {
+	cf.c.mu.Lock()
+	defer cf.c.mu.Unlock()
+	cf.c.refs++
+	return &closingFile{File: cf.File.Fork(), c: cf.c}
+}
+
+// Close closes the File, and the resources if no other fork is open.
+func (cf *closingFile) Close() error {
+	errs := []error{cf.File.Close()}
+	cf.c.mu.Lock()
+	defer cf.c.mu.Unlock()
+	if cf.closed {
+		return errs[0]
+	}
+	cf.closed = true
+	errs = append(errs, cf.c.release())
+	return errors.Join(errs...)
+}
+
+// release decrements the number of open forks, and closes the resources if it is 0. The mutex must be held.
+func (c *closers) release() error {
+	if c.refs--; c.refs > 0 {
+		return nil
+	}
+	var errs []error
+	for _, cl := range c.list {
+		errs = append(errs, cl.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,405 @@
+package rem
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Compression is a compression format.
+type Compression int
+
+const (
+	// NoCompression indicates that the input is not compressed.
+	NoCompression Compression = iota
+	// Gzip is the gzip format.
+	Gzip
+	// Zlib is the zlib format.
+	Zlib
+	// Flate is the raw DEFLATE format. It has no magic bytes, so it is never detected.
+	Flate
+	// Bzip2 is the bzip2 format.
+	Bzip2
+)
+
+// String returns the name of the compression format.
+func (c Compression) String() string {
+	switch c {
+	case NoCompression:
+		return "none"
+	case Gzip:
+		return "gzip"
+	case Zlib:
+		return "zlib"
+	case Flate:
+		return "flate"
+	case Bzip2:
+		return "bzip2"
+	}
+	return "Compression(" + strconv.Itoa(int(c)) + ")"
+}
+
+// zlibProbeSize is the number of bytes that DetectCompression decodes to confirm the zlib format.
+const zlibProbeSize = 512
+
+// DetectCompression detects the compression format of the input of br by its magic bytes, without consuming them.
+// The zlib header is only two bytes, and many texts start with a valid one, so the zlib format is confirmed by
+// decoding the start of the input.
+func DetectCompression(br *bufio.Reader) (Compression, error) {
+	magic, err := br.Peek(4)
+	if err != nil && err != io.EOF {
+		return NoCompression, err
+	}
+	switch {
+	case len(magic) >= 2 && magic[0] == 0x1f && magic[1] == 0x8b:
+		return Gzip, nil
+	case len(magic) >= 4 && string(magic[:3]) == "BZh" && magic[3] >= '1' && magic[3] <= '9':
+		return Bzip2, nil
+	case len(magic) >= 2 && magic[0]&0x0f == 8 && magic[0]>>4 <= 7 && magic[1]&0x20 == 0 &&
+		(uint16(magic[0])<<8|uint16(magic[1]))%31 == 0:
+		if isZlib(br) {
+			return Zlib, nil
+		}
+	}
+	return NoCompression, nil
+}
+
+// isZlib reports whether the bytes that br has buffered decode as the start of a zlib stream.
+func isZlib(br *bufio.Reader) bool {
+	probe, err := br.Peek(zlibProbeSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return false
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(probe))
+	if err != nil {
+		return false
+	}
+	_, err = io.Copy(io.Discard, zr)
+	return err == nil || err == io.ErrUnexpectedEOF
+}
+
+// WithCompression makes NewFileFromCompressed use the compression format c instead of detecting it. It is needed for
+// the Flate format.
+func WithCompression(c Compression) Option {
+	return func(o *options) {
+		o.compression = &c
+	}
+}
+
+// WithSeekIndex makes NewFileFromCompressed build a seek index while reading. The decompressed input is divided in blocks
+// of blockSize bytes, each block is compressed again and kept, so any block can be decompressed again without
+// decompressing the input from the start. The File then has random access, like the io.ReaderAt backend.
+//
+// The index costs a second compression of the whole input. The compressed blocks are stored like the bytes of the
+// io.Reader backend: in up to memLimit bytes of memory, then in up to diskLimit bytes of a file in tempDir. The blocks
+// before the offset consumed by all the forks are dropped, so only the unconsumed blocks take space. Besides that, the
+// index keeps up to two decompressed blocks in memory.
+func WithSeekIndex(blockSize int) Option {
+	return func(o *options) {
+		o.seekIndex = blockSize
+	}
+}
+
+// NewFileFromCompressed creates a new File that reads the decompressed input of r. The compression format is detected
+// by the magic bytes, if the input is not compressed it is read as is, with the backend that NewFileFromReader chooses
+// for r. Without the option WithSeekIndex, the File uses
+// the io.Reader backend, see NewFileFromReader for memLimit, diskLimit and tempDir. With it, memLimit, diskLimit and
+// tempDir limit the seek index. The decompressor is closed when the File and all its forks are closed.
+func NewFileFromCompressed(r io.Reader, memLimit, diskLimit int64, tempDir string, opts ...Option) (File, error) {
+	o := newOptions(opts)
+	c := NoCompression
+	if o.compression != nil {
+		c = *o.compression
+	} else {
+		var err error
+		if c, r, err = detectCompression(r); err != nil {
+			return nil, err
+		}
+	}
+
+	var dr io.Reader
+	var closers []io.Closer
+	switch c {
+	case NoCompression:
+		dr = r
+	case Gzip:
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		dr, closers = zr, []io.Closer{zr}
+	case Zlib:
+		zr, err := zlib.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		dr, closers = zr, []io.Closer{zr}
+	case Flate:
+		fr := flate.NewReader(r)
+		dr, closers = fr, []io.Closer{fr}
+	case Bzip2:
+		dr = bzip2.NewReader(r)
+	default:
+		return nil, errors.New("invalid compression format")
+	}
+
+	if o.seekIndex > 0 {
+		f := newIndexedFile(dr, o.seekIndex, memLimit, diskLimit, tempDir)
+		return newClosingFile(withContext(o.ctx, f), closers...), nil
+	}
+	return newClosingFile(NewFileFromReader(dr, memLimit, diskLimit, tempDir, opts...), closers...), nil
+}
+
+// detectCompression detects the compression format of the input of r. It returns the reader of the input, that is r
+// if the detection did not consume the input of r, like for a *bytes.Buffer, an io.ReadSeeker or an io.ReaderAt, so
+// an uncompressed input keeps the backend of r. Otherwise it is a bufio.Reader with the peeked bytes.
+func detectCompression(r io.Reader) (Compression, io.Reader, error) {
+	switch src := r.(type) {
+	case *bytes.Buffer:
+		c, err := DetectCompression(bufio.NewReader(bytes.NewReader(src.Bytes())))
+		return c, r, err
+	case io.ReadSeeker:
+		pos, err := src.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return NoCompression, nil, err
+		}
+		c, err := DetectCompression(bufio.NewReader(src))
+		if _, serr := src.Seek(pos, io.SeekStart); err == nil {
+			err = serr
+		}
+		return c, r, err
+	case io.ReaderAt:
+		c, err := DetectCompression(bufio.NewReader(io.NewSectionReader(src, 0, math.MaxInt64)))
+		return c, r, err
+	}
+	br := bufio.NewReader(r)
+	c, err := DetectCompression(br)
+	return c, br, err
+}
+
+// indexedFile is a File that reads a seekIndex. Forks share the seekIndex.
+type indexedFile struct {
+	*readerAt
+	// si is the seek index.
+	si *seekIndex
+}
+
+// newIndexedFile creates a new indexedFile that reads src with a seek index of blocks of blockSize bytes, stored as the
+// storage of the io.Reader backend.
+func newIndexedFile(src io.Reader, blockSize int, memLimit, diskLimit int64, tempDir string) *indexedFile {
+	w, _ := flate.NewWriter(nil, flate.BestSpeed)
+	si := &seekIndex{
+		src:       src,
+		blockSize: blockSize,
+		store:     newStorage(strings.NewReader(""), memLimit, diskLimit, tempDir),
+		cached:    -1,
+		w:         w,
+		cursors:   make(map[*indexedFile]int64),
+	}
+	f := &indexedFile{readerAt: newReaderAt(si), si: si}
+	si.cursors[f] = 0
+	return f
+}
+
+// Consumed marks the bytes before offset as consumed. The blocks consumed by all the forks are dropped from the index.
+func (f *indexedFile) Consumed(offset int64) {
+	if offset > f.Offset() {
+		panic(errors.New("invalid offset"))
+	}
+	if !f.tx.deferConsumed(offset) {
+		f.consume(offset)
+	}
+}
+
+// consume marks the bytes before offset as consumed.
+func (f *indexedFile) consume(offset int64) {
+	f.si.mu.Lock()
+	defer f.si.mu.Unlock()
+	if consumed, ok := f.si.cursors[f]; ok {
+		f.si.cursors[f] = max(consumed, offset)
+		f.si.drop()
+	}
+}
+
+// Begin begins a transaction at the current offset. Transactions can be nested.
+func (f *indexedFile) Begin() Tx {
+	return f.tx.begin(f)
+}
+
+// Fork returns a new indexedFile that shares the seek index of f, with an independent offset initially equal to the
+// current offset of f.
+func (f *indexedFile) Fork() File {
+	f.si.mu.Lock()
+	defer f.si.mu.Unlock()
+	fork := &indexedFile{readerAt: &readerAt{ra: f.ra, offset: f.offset}, si: f.si}
+	f.si.cursors[fork] = f.si.cursors[f]
+	return fork
+}
+
+// Close releases the seek index when the last of the forks that share it is closed.
+func (f *indexedFile) Close() error {
+	f.si.mu.Lock()
+	defer f.si.mu.Unlock()
+	if _, ok := f.si.cursors[f]; !ok {
+		return nil
+	}
+	delete(f.si.cursors, f)
+	if len(f.si.cursors) > 0 {
+		f.si.drop()
+		return nil
+	}
+	f.si.blocks, f.si.tail, f.si.cache = nil, nil, nil
+	return f.si.store.Close()
+}
+
+// seekIndex is a io.ReaderAt over a stream that is read sequentially. It keeps the stream read so far divided in
+// blocks, each block compressed independently. The blocks before the offset consumed by all the cursors are dropped.
+type seekIndex struct {
+	mu sync.Mutex
+	// src is the stream.
+	src io.Reader
+	// blockSize is the size of the decompressed blocks.
+	blockSize int
+	// store keeps the compressed blocks, in memory or on disk.
+	store *storage
+	// dropped is the number of blocks dropped from the start of the index.
+	dropped int
+	// blocks are the complete blocks that were not dropped.
+	blocks []indexBlock
+	// tail is the incomplete block at the end of the stream read so far.
+	tail []byte
+	// err is the error returned by src, it is io.EOF at the end of the stream.
+	err error
+	// cached is the index of the block in cache, or -1.
+	cached int
+	// cache is the last block decompressed.
+	cache []byte
+	// w compresses the blocks.
+	w *flate.Writer
+	// cursors are the consumed offsets of the indexedFiles that use the index and are not closed.
+	cursors map[*indexedFile]int64
+}
+
+// indexBlock is a block compressed with flate.
+type indexBlock struct {
+	// offset is the offset of the block in the store.
+	offset int64
+	// size is the compressed size of the block.
+	size int
+}
+
+// ReadAt implements io.ReaderAt. It reads the stream as needed.
+func (si *seekIndex) ReadAt(p []byte, off int64) (n int, err error) {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	for n < len(p) {
+		pos := off + int64(n)
+		for pos >= si.size() && si.err == nil {
+			si.fill()
+		}
+		if pos >= si.size() {
+			return n, si.err
+		}
+		b := int(pos / int64(si.blockSize))
+		data, err := si.block(b)
+		if err != nil {
+			return n, err
+		}
+		n += copy(p[n:], data[pos-int64(b)*int64(si.blockSize):])
+	}
+	return n, nil
+}
+
+// size returns the number of bytes of the stream read so far.
+func (si *seekIndex) size() int64 {
+	return int64(si.dropped+len(si.blocks))*int64(si.blockSize) + int64(len(si.tail))
+}
+
+// fill reads the stream until the tail is complete, then it compresses the tail into a new block.
+func (si *seekIndex) fill() {
+	if si.tail == nil {
+		si.tail = make([]byte, 0, si.blockSize)
+	}
+	m, err := io.ReadFull(si.src, si.tail[len(si.tail):si.blockSize])
+	si.tail = si.tail[:len(si.tail)+m]
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	si.err = err
+	if len(si.tail) < si.blockSize {
+		return
+	}
+
+	var buf bytes.Buffer
+	si.w.Reset(&buf)
+	if _, err = si.w.Write(si.tail); err == nil {
+		err = si.w.Close()
+	}
+	blk := indexBlock{offset: si.store.writeOffset, size: buf.Len()}
+	if err == nil {
+		_, err = si.store.Write(buf.Bytes())
+	}
+	if err != nil {
+		si.err = err
+		return
+	}
+	si.blocks = append(si.blocks, blk)
+	si.cached, si.cache = si.dropped+len(si.blocks)-1, si.tail
+	si.tail = nil
+}
+
+// block returns the decompressed block b.
+func (si *seekIndex) block(b int) ([]byte, error) {
+	if b == si.dropped+len(si.blocks) {
+		return si.tail, nil
+	}
+	if b == si.cached {
+		return si.cache, nil
+	}
+	if b < si.dropped {
+		return nil, errors.New("the block was consumed")
+	}
+	blk := si.blocks[b-si.dropped]
+	compressed := make([]byte, blk.size)
+	if _, err := si.store.ReadAt(compressed, blk.offset); err != nil {
+		return nil, err
+	}
+	data := make([]byte, si.blockSize)
+	if _, err := io.ReadFull(flate.NewReader(bytes.NewReader(compressed)), data); err != nil {
+		return nil, err
+	}
+	si.cached, si.cache = b, data
+	return si.cache, nil
+}
+
+// drop drops the blocks consumed by all the cursors, and reuses their space in the store.
+func (si *seekIndex) drop() {
+	consumed := si.size()
+	for _, c := range si.cursors {
+		consumed = min(consumed, c)
+	}
+	n := int(consumed/int64(si.blockSize)) - si.dropped
+	if n <= 0 {
+		return
+	}
+	n = min(n, len(si.blocks))
+	si.dropped += n
+	si.blocks = si.blocks[n:]
+	if si.cached >= 0 && si.cached < si.dropped {
+		si.cached, si.cache = -1, nil
+	}
+	if len(si.blocks) > 0 {
+		si.store.reclaim(si.blocks[0].offset)
+	} else {
+		si.store.reclaim(si.store.writeOffset)
+	}
+}
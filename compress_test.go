@@ -0,0 +1,243 @@
+package rem
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// compress compresses data in the format c.
+func compress(t *testing.T, c Compression, data string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch c {
+	case NoCompression:
+		return []byte(data)
+	case Gzip:
+		w = gzip.NewWriter(&buf)
+	case Zlib:
+		w = zlib.NewWriter(&buf)
+	case Flate:
+		w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	default:
+		t.Fatalf("unsupported compression %v", c)
+	}
+	if _, err := io.WriteString(w, data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// bzip2Data is "hello, world\n" compressed with bzip2.
+var bzip2Data = []byte{
+	0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0x54, 0xa4, 0x97, 0x84, 0x00, 0x00,
+	0x02, 0xd1, 0x80, 0x00, 0x10, 0x40, 0x04, 0x06, 0x44, 0x90, 0x80, 0x20, 0x00, 0x31, 0x00, 0x30,
+	0x20, 0x68, 0x62, 0x00, 0x49, 0xd4, 0xb2, 0x1f, 0x3f, 0x17, 0x72, 0x45, 0x38, 0x50, 0x90, 0x54,
+	0xa4, 0x97, 0x84,
+}
+
+// readString reads f until the EOF.
+func readString(f File) string {
+	var sb strings.Builder
+	for r, eof := f.Next(); !eof; r, eof = f.Next() {
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// TestDetectCompression tests the detection of the compression formats.
+func TestDetectCompression(t *testing.T) {
+	tests := []struct {
+		data []byte
+		c    Compression
+	}{
+		{compress(t, Gzip, "abc"), Gzip},
+		{compress(t, Zlib, "abc"), Zlib},
+		{bzip2Data, Bzip2},
+		{[]byte("abc"), NoCompression},
+		{[]byte("BZh0"), NoCompression},
+		{[]byte("80 apples\n"), NoCompression},
+		{[]byte("x = 1\n"), NoCompression},
+		{[]byte("Hjalmar\n"), NoCompression},
+		{[]byte("HKEY_LOCAL\n"), NoCompression},
+		{nil, NoCompression},
+	}
+	for i, test := range tests {
+		c, err := DetectCompression(bufio.NewReader(bytes.NewReader(test.data)))
+		if err != nil {
+			t.Errorf("test %d: unexpected error %v", i, err)
+		} else if c != test.c {
+			t.Errorf("test %d: expected %v, got %v", i, test.c, c)
+		}
+	}
+	if s := Compression(9).String(); s != "Compression(9)" {
+		t.Errorf("unexpected string %q", s)
+	}
+}
+
+// TestNewFileFromCompressed tests if the Files read the decompressed input, with and without seek index.
+func TestNewFileFromCompressed(t *testing.T) {
+	data := strings.Repeat("0123456789abcdef", 100) + "çã"
+	for _, c := range []Compression{NoCompression, Gzip, Zlib, Flate} {
+		for _, blockSize := range []int{0, 7, 64} {
+			opts := []Option{WithSeekIndex(blockSize)}
+			if c == Flate {
+				opts = append(opts, WithCompression(Flate))
+			}
+			f, err := NewFileFromCompressed(bytes.NewReader(compress(t, c, data)), 32, 1<<12, t.TempDir(), opts...)
+			if err != nil {
+				t.Fatalf("%v, %d: unexpected error %v", c, blockSize, err)
+			}
+			if got := readString(f); got != data {
+				t.Errorf("%v, %d: unexpected content %q", c, blockSize, got)
+			}
+			if blockSize > 0 {
+				runes := []rune(data)
+				for i := len(runes) - 1; i >= 0; i-- {
+					if r, _ := f.Previous(); r != runes[i] {
+						t.Fatalf("%v, %d: expected %q at %d, got %q", c, blockSize, runes[i], i, r)
+					}
+				}
+				if _, onStart := f.Previous(); !onStart {
+					t.Errorf("%v, %d: expected the start", c, blockSize)
+				}
+				if idx := f.IndexString("ç"); idx != int64(len(data)-4) {
+					t.Errorf("%v, %d: unexpected index %d", c, blockSize, idx)
+				}
+			}
+			if err := f.Close(); err != nil {
+				t.Errorf("%v, %d: unexpected error %v", c, blockSize, err)
+			}
+		}
+	}
+
+	f, err := NewFileFromCompressed(bytes.NewReader(bzip2Data), 32, 1<<12, t.TempDir(), WithSeekIndex(4))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	defer f.Close()
+	if got := readString(f); got != "hello, world\n" {
+		t.Errorf("unexpected content %q", got)
+	}
+}
+
+// TestSeekIndexConsumed tests if the seek index drops the blocks consumed by all the forks.
+func TestSeekIndexConsumed(t *testing.T) {
+	data := strings.Repeat("0123456789abcdef", 1<<12)
+	newFile := func() File {
+		f, err := NewFileFromCompressed(bytes.NewReader(compress(t, Gzip, data)), 256, 256, t.TempDir(), WithSeekIndex(64))
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		return f
+	}
+
+	f := newFile()
+	fork := f.Fork()
+	for i := 0; i < len(data); i++ {
+		if _, eof := f.Next(); eof {
+			t.Fatalf("unexpected EOF at %d", i)
+		}
+		f.Consumed(f.Offset())
+		if i == 100 {
+			fork.Next()
+			fork.Consumed(fork.Offset())
+			fork.Close()
+		}
+	}
+	if _, eof := f.Next(); !eof {
+		t.Errorf("EOF expected")
+	}
+	if err := f.Close(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	f = newFile()
+	defer f.Close()
+	fork = f.Fork()
+	defer fork.Close()
+	defer func() {
+		if recover() == nil {
+			t.Errorf("panic expected")
+		}
+	}()
+	for {
+		if _, eof := f.Next(); eof {
+			break
+		}
+		f.Consumed(f.Offset())
+	}
+}
+
+// TestNewFileFromCompressedText tests if texts that start with a valid zlib header are read as is.
+func TestNewFileFromCompressedText(t *testing.T) {
+	for _, text := range []string{"80 apples\n", "x = 1\n", "Hjalmar\n", "HKEY_LOCAL\n"} {
+		f, err := NewFileFromCompressed(strings.NewReader(text), 32, 1<<12, t.TempDir())
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", text, err)
+			continue
+		}
+		if got := readString(f); got != text {
+			t.Errorf("%q: unexpected content %q", text, got)
+		}
+		f.Close()
+	}
+}
+
+// TestNewFileFromCompressedBackend tests if an uncompressed input keeps the backend that NewFileFromReader chooses, and
+// if the detection does not consume the input of a compressed one.
+func TestNewFileFromCompressedBackend(t *testing.T) {
+	tests := []struct {
+		r io.Reader
+		f File
+	}{
+		{bytes.NewBuffer([]byte("abc")), &reader{}},
+		{strings.NewReader("abc"), &seeker{}},
+		{newTestReaderAt("abc"), &readerAt{}},
+		{bufio.NewReader(strings.NewReader("abc")), &reader{}},
+	}
+	for _, test := range tests {
+		f, err := NewFileFromCompressed(test.r, 32, 1<<12, t.TempDir())
+		if err != nil {
+			t.Fatalf("%T: unexpected error %v", test.r, err)
+		}
+		if inner := f.(*closingFile).File; fmt.Sprintf("%T", inner) != fmt.Sprintf("%T", test.f) {
+			t.Errorf("%T: expected a %T, got %T", test.r, test.f, inner)
+		}
+		if got := readString(f); got != "abc" {
+			t.Errorf("%T: unexpected content %q", test.r, got)
+		}
+		f.Close()
+	}
+
+	for _, r := range []io.Reader{bytes.NewBuffer(compress(t, Gzip, "abc")), bytes.NewReader(compress(t, Gzip, "abc"))} {
+		f, err := NewFileFromCompressed(r, 32, 1<<12, t.TempDir())
+		if err != nil {
+			t.Fatalf("%T: unexpected error %v", r, err)
+		}
+		if got := readString(f); got != "abc" {
+			t.Errorf("%T: unexpected content %q", r, got)
+		}
+		f.Close()
+	}
+}
+
+// TestNewFileFromCompressedError tests the errors of invalid inputs.
+func TestNewFileFromCompressedError(t *testing.T) {
+	if _, err := NewFileFromCompressed(strings.NewReader("\x1f\x8bxxxxxxxxxx"), 32, 1<<12, t.TempDir()); err == nil {
+		t.Errorf("error expected")
+	}
+	if _, err := NewFileFromCompressed(strings.NewReader("abc"), 32, 1<<12, t.TempDir(), WithCompression(Compression(9))); err == nil {
+		t.Errorf("error expected")
+	}
+}
@@ -0,0 +1,246 @@
+package rem
+
+import (
+	"errors"
+	"unicode/utf8"
+)
+
+// ConcatFile is a File that reads several Files in sequence, as if they were a single input.
+type ConcatFile interface {
+	File
+
+	// Member returns the index of the member at the current offset, and the offset within the member, that is, the
+	// Offset of the member. At a boundary, the member is the one before the boundary, unless it was not read yet.
+	Member() (i int, offset int64)
+}
+
+// concat is a ConcatFile.
+type concat struct {
+	// files are the members. The members fully consumed are closed and set to nil.
+	files []File
+	// origins are the offsets of the members when they were added.
+	origins []int64
+	// starts are the offsets of the starts of the members in the concatenation, from the first member to the current.
+	starts []int64
+	// cur is the index of the current member. The members before it are at their ends, and the members after it are at
+	// their origins.
+	cur int
+	// first is the index of the first member not closed.
+	first int
+	// tx are the open transactions.
+	tx transactions
+}
+
+// Concat returns a ConcatFile that reads files in sequence, each one from its current offset. Next and Previous move
+// across the boundaries, but a rune can not be split between members. The offsets start at 0. Consumed closes the
+// members that are fully consumed, and Close closes all the members that are not closed.
+func Concat(files ...File) ConcatFile {
+	c := &concat{files: files, origins: make([]int64, len(files)), starts: []int64{0}}
+	for i, f := range files {
+		c.origins[i] = f.Offset()
+	}
+	return c
+}
+
+// Member returns the index of the member at the current offset, and the offset within the member.
+func (c *concat) Member() (i int, offset int64) {
+	if len(c.files) == 0 {
+		return 0, 0
+	}
+	return c.cur, c.files[c.cur].Offset()
+}
+
+// advance makes the next member the current, if there is one.
+func (c *concat) advance() bool {
+	if c.cur+1 >= len(c.files) {
+		return false
+	}
+	c.starts = append(c.starts[:c.cur+1], c.Offset())
+	c.cur++
+	return true
+}
+
+// retreat makes the previous member the current, if there is one that is not closed.
+func (c *concat) retreat() bool {
+	if c.cur <= c.first {
+		return false
+	}
+	c.cur--
+	c.starts = c.starts[:c.cur+1]
+	return true
+}
+
+// Next returns the rune at the current offset, moving to the next member at the end of the current one.
+func (c *concat) Next() (r rune, eof bool) {
+	if len(c.files) == 0 {
+		return 0, true
+	}
+	for {
+		if r, eof = c.files[c.cur].Next(); !eof || !c.advance() {
+			return r, eof
+		}
+	}
+}
+
+// Previous returns the rune before the current offset, moving to the previous member at the start of the current one.
+func (c *concat) Previous() (r rune, onStart bool) {
+	if len(c.files) == 0 {
+		return 0, true
+	}
+	for {
+		if c.files[c.cur].Offset() > c.origins[c.cur] {
+			if r, onStart = c.files[c.cur].Previous(); !onStart {
+				return r, false
+			}
+		}
+		if !c.retreat() {
+			return 0, true
+		}
+	}
+}
+
+// NextByte returns the byte at the current offset, moving to the next member at the end of the current one.
+func (c *concat) NextByte() (b byte, eof bool) {
+	if len(c.files) == 0 {
+		return 0, true
+	}
+	for {
+		if b, eof = c.files[c.cur].NextByte(); !eof || !c.advance() {
+			return b, eof
+		}
+	}
+}
+
+// PreviousByte returns the byte before the current offset, moving to the previous member at the start of the current
+// one.
+func (c *concat) PreviousByte() (b byte, onStart bool) {
+	if len(c.files) == 0 {
+		return 0, true
+	}
+	for {
+		if c.files[c.cur].Offset() > c.origins[c.cur] {
+			if b, onStart = c.files[c.cur].PreviousByte(); !onStart {
+				return b, false
+			}
+		}
+		if !c.retreat() {
+			return 0, true
+		}
+	}
+}
+
+// ReadBytes returns the next n bytes, reading as many members as needed.
+func (c *concat) ReadBytes(n int) (p []byte, eof bool) {
+	if len(c.files) == 0 {
+		return nil, true
+	}
+	for {
+		q, eof := c.files[c.cur].ReadBytes(n - len(p))
+		p = append(p, q...)
+		if len(p) == n {
+			return p, false
+		}
+		if eof && !c.advance() {
+			return p, true
+		}
+	}
+}
+
+// IndexRune returns the offset of the first instance of r at or after the current offset, or -1 if r is not present.
+func (c *concat) IndexRune(r rune) int64 {
+	return indexFile(c, utf8.AppendRune(nil, r))
+}
+
+// IndexString returns the offset of the first instance of s at or after the current offset, or -1 if s is not present.
+func (c *concat) IndexString(s string) int64 {
+	return indexFile(c, []byte(s))
+}
+
+// LastIndexRune returns the offset of the last instance of r that ends at or before the current offset, or -1 if r is
+// not present.
+func (c *concat) LastIndexRune(r rune) int64 {
+	return lastIndexFile(c, utf8.AppendRune(nil, r))
+}
+
+// LastIndexString returns the offset of the last instance of s that ends at or before the current offset, or -1 if s
+// is not present.
+func (c *concat) LastIndexString(s string) int64 {
+	return lastIndexFile(c, []byte(s))
+}
+
+// SkipUntil put the offset at the start of the first instance of delim at or after the current offset.
+func (c *concat) SkipUntil(delim string) (found bool) {
+	return skipFile(c, []byte(delim))
+}
+
+// Consumed marks the bytes before offset as consumed. The members that end at or before offset, and are not the
+// current member, are closed.
+func (c *concat) Consumed(offset int64) {
+	if offset > c.Offset() {
+		panic(errors.New("invalid offset"))
+	}
+	if !c.tx.deferConsumed(offset) {
+		c.consume(offset)
+	}
+}
+
+// consume closes the members that end at or before offset, and marks the bytes before offset as consumed in the member
+// that contains offset.
+func (c *concat) consume(offset int64) {
+	for c.first < c.cur && c.starts[c.first+1] <= offset {
+		err := c.files[c.first].Close()
+		c.files[c.first] = nil
+		c.first++
+		if err != nil {
+			panic(err)
+		}
+	}
+	if len(c.files) > 0 && offset >= c.starts[c.first] {
+		c.files[c.first].Consumed(c.origins[c.first] + offset - c.starts[c.first])
+	}
+}
+
+// Begin begins a transaction at the current offset. Transactions can be nested.
+func (c *concat) Begin() Tx {
+	return c.tx.begin(c)
+}
+
+// setOffset put the offset at offset.
+func (c *concat) setOffset(offset int64) {
+	seekFile(c, offset)
+}
+
+// Fork returns a new ConcatFile with forks of the members that are not closed, with an independent offset initially
+// equal to the current offset of c.
+func (c *concat) Fork() File {
+	fork := &concat{
+		files:   make([]File, len(c.files)),
+		origins: c.origins,
+		starts:  append([]int64(nil), c.starts...),
+		cur:     c.cur,
+		first:   c.first,
+	}
+	for i := c.first; i < len(c.files); i++ {
+		fork.files[i] = c.files[i].Fork()
+	}
+	return fork
+}
+
+// Offset returns the current offset.
+func (c *concat) Offset() int64 {
+	if len(c.files) == 0 {
+		return 0
+	}
+	return c.starts[c.cur] + c.files[c.cur].Offset() - c.origins[c.cur]
+}
+
+// Close closes all the members that are not closed.
+func (c *concat) Close() error {
+	var errs []error
+	for i := c.first; i < len(c.files); i++ {
+		errs = append(errs, c.files[i].Close())
+		c.files[i] = nil
+	}
+	c.first = len(c.files)
+	return errors.Join(errs...)
+}
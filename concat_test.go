@@ -0,0 +1,134 @@
+package rem
+
+import (
+	"strings"
+	"testing"
+)
+
+// closeCounter is a File that counts the calls to Close.
+type closeCounter struct {
+	File
+	closed *int
+}
+
+// Close counts the call and closes the File.
+func (cc closeCounter) Close() error {
+	*cc.closed++
+	return cc.File.Close()
+}
+
+// TestConcat tests the reading across the boundaries of the members.
+func TestConcat(t *testing.T) {
+	second := NewFileFromString("xxcç")
+	second.ReadBytes(2)
+	c := Concat(NewFileFromString("ab"), NewFile(nil), second, NewFileFromReader(strings.NewReader("de"), 4, 0, ""))
+	defer c.Close()
+
+	if got := readString(c); got != "abcçde" {
+		t.Errorf("unexpected content %q", got)
+	}
+	if offset := c.Offset(); offset != 7 {
+		t.Errorf("unexpected offset %d", offset)
+	}
+	if i, offset := c.Member(); i != 3 || offset != 2 {
+		t.Errorf("unexpected member %d, %d", i, offset)
+	}
+
+	for _, want := range "edçcba" {
+		if r, onStart := c.Previous(); onStart || r != want {
+			t.Fatalf("expected %q, got %q", want, r)
+		}
+	}
+	if _, onStart := c.Previous(); !onStart {
+		t.Errorf("expected the start")
+	}
+	c.ReadBytes(3)
+	if i, offset := c.Member(); i != 2 || offset != 3 {
+		t.Errorf("unexpected member %d, %d", i, offset)
+	}
+	if p, eof := c.ReadBytes(10); !eof || string(p) != "çde" {
+		t.Errorf("unexpected result %q, %v", p, eof)
+	}
+	if b, _ := c.PreviousByte(); b != 'e' {
+		t.Errorf("expected 'e', got %q", b)
+	}
+}
+
+// TestConcatSearch tests the searches across the boundaries of the members.
+func TestConcatSearch(t *testing.T) {
+	c := Concat(NewFileFromString("abc"), NewFileFromString("dab"), NewFileFromString("cd"))
+	defer c.Close()
+	if i := c.IndexString("cda"); i != 2 {
+		t.Errorf("unexpected index %d", i)
+	}
+	if i := c.IndexRune('x'); i != -1 {
+		t.Errorf("unexpected index %d", i)
+	}
+	if !c.SkipUntil("bcd") {
+		t.Errorf("expected that the delimiter was found")
+	}
+	if offset := c.Offset(); offset != 1 {
+		t.Errorf("unexpected offset %d", offset)
+	}
+	c.ReadBytes(10)
+	if i := c.LastIndexString("abc"); i != 4 {
+		t.Errorf("unexpected index %d", i)
+	}
+	if i := c.LastIndexRune('d'); i != 7 {
+		t.Errorf("unexpected index %d", i)
+	}
+	if c.SkipUntil("x") || c.Offset() != 8 {
+		t.Errorf("expected the EOF")
+	}
+}
+
+// TestConcatConsumed tests if the members fully consumed are closed, also inside transactions and forks.
+func TestConcatConsumed(t *testing.T) {
+	closed := 0
+	c := Concat(closeCounter{NewFileFromString("ab"), &closed}, closeCounter{NewFileFromString("cd"), &closed},
+		closeCounter{NewFileFromString("ef"), &closed})
+
+	c.ReadBytes(3)
+	tx := c.Begin()
+	c.Consumed(3)
+	if closed != 0 {
+		t.Errorf("expected that the members were not closed inside a transaction")
+	}
+	c.ReadBytes(2)
+	tx.Rollback()
+	if offset := c.Offset(); offset != 3 {
+		t.Errorf("unexpected offset %d", offset)
+	}
+	if closed != 0 {
+		t.Errorf("expected that the members were not closed by the rollback")
+	}
+	c.Consumed(3)
+	if closed != 1 {
+		t.Errorf("expected 1 member closed, got %d", closed)
+	}
+	c.Previous()
+	if _, onStart := c.Previous(); !onStart {
+		t.Errorf("expected the start of the members not closed")
+	}
+	if offset := c.Offset(); offset != 2 {
+		t.Errorf("unexpected offset %d", offset)
+	}
+
+	fork := c.Fork()
+	if got := readString(fork); got != "cdef" {
+		t.Errorf("unexpected content %q", got)
+	}
+	if err := fork.Close(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if closed != 3 {
+		t.Errorf("expected 3 members closed, got %d", closed)
+	}
+
+	if _, eof := Concat().Next(); !eof {
+		t.Errorf("expected EOF")
+	}
+}
@@ -0,0 +1,132 @@
+package rem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrCanceled is wrapped by the errors that the File methods panic with when the context of the File is done. The error
+// also wraps the error of the context, so errors.Is(err, context.DeadlineExceeded) reports whether a deadline expired.
+var ErrCanceled = errors.New("canceled")
+
+// WithContext makes the methods of the File that read the input panic with an error that wraps ErrCanceled once ctx is
+// done. For the io.Reader backend, the input is read by a goroutine, like with WithPrefetch, so a call blocked waiting
+// for the input returns when ctx is done. Close must still be called, it removes the temporary files.
+func WithContext(ctx context.Context) Option {
+	return func(o *options) {
+		o.ctx = ctx
+	}
+}
+
+// canceledError returns the error that wraps ErrCanceled and the error of ctx.
+func canceledError(ctx context.Context) error {
+	return fmt.Errorf("%w: %w", ErrCanceled, ctx.Err())
+}
+
+// contextFile is a File that checks a context before each read.
+type contextFile struct {
+	ctx context.Context
+	// f is the File that reads the input.
+	f File
+}
+
+// withContext returns f wrapped by a contextFile if ctx is not nil.
+func withContext(ctx context.Context, f File) File {
+	if ctx == nil {
+		return f
+	}
+	return &contextFile{ctx: ctx, f: f}
+}
+
+// check panics if the context is done.
+func (cf *contextFile) check() {
+	if cf.ctx.Err() != nil {
+		panic(canceledError(cf.ctx))
+	}
+}
+
+// Next checks the context and calls the Next method of the File.
+func (cf *contextFile) Next() (r rune, eof bool) {
+	cf.check()
+	return cf.f.Next()
+}
+
+// Previous checks the context and calls the Previous method of the File.
+func (cf *contextFile) Previous() (r rune, onStart bool) {
+	cf.check()
+	return cf.f.Previous()
+}
+
+// Consumed calls the Consumed method of the File.
+func (cf *contextFile) Consumed(offset int64) {
+	cf.f.Consumed(offset)
+}
+
+// Offset calls the Offset method of the File.
+func (cf *contextFile) Offset() int64 {
+	return cf.f.Offset()
+}
+
+// NextByte checks the context and calls the NextByte method of the File.
+func (cf *contextFile) NextByte() (b byte, eof bool) {
+	cf.check()
+	return cf.f.NextByte()
+}
+
+// PreviousByte checks the context and calls the PreviousByte method of the File.
+func (cf *contextFile) PreviousByte() (b byte, onStart bool) {
+	cf.check()
+	return cf.f.PreviousByte()
+}
+
+// ReadBytes checks the context and calls the ReadBytes method of the File.
+func (cf *contextFile) ReadBytes(n int) (p []byte, eof bool) {
+	cf.check()
+	return cf.f.ReadBytes(n)
+}
+
+// IndexRune checks the context and calls the IndexRune method of the File.
+func (cf *contextFile) IndexRune(r rune) int64 {
+	cf.check()
+	return cf.f.IndexRune(r)
+}
+
+// IndexString checks the context and calls the IndexString method of the File.
+func (cf *contextFile) IndexString(s string) int64 {
+	cf.check()
+	return cf.f.IndexString(s)
+}
+
+// LastIndexRune checks the context and calls the LastIndexRune method of the File.
+func (cf *contextFile) LastIndexRune(r rune) int64 {
+	cf.check()
+	return cf.f.LastIndexRune(r)
+}
+
+// LastIndexString checks the context and calls the LastIndexString method of the File.
+func (cf *contextFile) LastIndexString(s string) int64 {
+	cf.check()
+	return cf.f.LastIndexString(s)
+}
+
+// SkipUntil checks the context and calls the SkipUntil method of the File.
+func (cf *contextFile) SkipUntil(delim string) (found bool) {
+	cf.check()
+	return cf.f.SkipUntil(delim)
+}
+
+// Begin calls the Begin method of the File.
+func (cf *contextFile) Begin() Tx {
+	return cf.f.Begin()
+}
+
+// Fork calls the Fork method of the File and returns the new File with the same context.
+func (cf *contextFile) Fork() File {
+	return &contextFile{ctx: cf.ctx, f: cf.f.Fork()}
+}
+
+// Close calls the Close method of the File.
+func (cf *contextFile) Close() error {
+	return cf.f.Close()
+}
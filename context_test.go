@@ -0,0 +1,78 @@
+package rem
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// expectCanceled calls fn and checks if it panics with an error that wraps ErrCanceled and target.
+func expectCanceled(t *testing.T, target error, fn func()) {
+	t.Helper()
+	defer func() {
+		t.Helper()
+		err, _ := recover().(error)
+		if err == nil {
+			t.Errorf("panic expected")
+			return
+		}
+		if !errors.Is(err, ErrCanceled) || !errors.Is(err, target) {
+			t.Errorf("expected an error that wraps %v and %v, got %v", ErrCanceled, target, err)
+		}
+	}()
+	fn()
+}
+
+// TestContextBlockedRead tests if a Next blocked waiting for the input returns when the context is canceled, and if
+// the temporary file is removed by Close.
+func TestContextBlockedRead(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	go io.WriteString(pw, "abcdefgh")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f := NewFileFromReader(pr, 4, 1<<10, t.TempDir(), WithContext(ctx))
+	r := f.(*contextFile).f.(*reader)
+	f.ReadBytes(8)
+	if r.s.disk == nil {
+		t.Fatalf("expected that the storage created a temporary file")
+	}
+	name := r.s.disk.Name()
+
+	time.AfterFunc(10*time.Millisecond, cancel)
+	expectCanceled(t, context.Canceled, func() { f.Next() })
+	expectCanceled(t, context.Canceled, func() { f.Previous() })
+
+	if err := f.Close(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, err := os.Stat(name); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected that the temporary file was removed, got %v", err)
+	}
+}
+
+// TestContextDeadline tests the context on the backends that do not block.
+func TestContextDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+
+	files := []File{
+		NewFileFromReader(strings.NewReader("test"), 4, 0, t.TempDir(), WithContext(ctx)),
+		NewFileFromReader(newTestReaderAt("test"), 4, 0, t.TempDir(), WithContext(ctx)),
+	}
+	for _, f := range files {
+		if f.Offset() != 0 {
+			t.Errorf("%T: expected offset = 0, got %d", f, f.Offset())
+		}
+		expectCanceled(t, context.DeadlineExceeded, func() { f.Next() })
+		expectCanceled(t, context.DeadlineExceeded, func() { f.Fork().IndexString("t") })
+		if err := f.Close(); err != nil {
+			t.Errorf("%T: unexpected error %v", f, err)
+		}
+	}
+}
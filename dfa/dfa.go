@@ -0,0 +1,236 @@
+// This package generates longest-match lexers that read from a rem.File. The tokens are declared by regular
+// expressions that are compiled into a DFA once.
+package dfa
+
+import (
+	"fmt"
+	"regexp/syntax"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/joaobnv/rem"
+	"github.com/joaobnv/rem/lex"
+)
+
+// Rule associates a regular expression with a token type.
+type Rule struct {
+	// Pattern is a regular expression in the syntax of the regexp package. Anchors, word boundaries and non-greedy
+	// operators are not supported.
+	Pattern string
+	// Type is the type of the tokens matched by Pattern.
+	Type lex.TokenType
+	// Action, if not nil, is called with each token matched by Pattern. It can modify the token. If it returns false,
+	// the token is discarded, this is useful for whitespaces and comments.
+	Action func(t *lex.Token) bool
+}
+
+// transition is a transition of a DFA state labeled by the runes in [lo, hi].
+type transition struct {
+	lo, hi rune
+	next   int
+}
+
+// state is a DFA state.
+type state struct {
+	// trans are the transitions of the state sorted by lo.
+	trans []transition
+	// accept is the index of the rule accepted by the state, or -1 if the state is not accepting.
+	accept int
+}
+
+// DFA is a compiled set of rules. A DFA is immutable, so it can be shared by many scanners.
+type DFA struct {
+	rules  []Rule
+	states []state
+}
+
+// Compile compiles rules into a DFA. When more than one rule matches the longest token, the first of them is chosen.
+func Compile(rules []Rule) (*DFA, error) {
+	n := &nfa{}
+	start := n.newState()
+	for i, rule := range rules {
+		re, err := syntax.Parse(rule.Pattern, syntax.Perl)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i, err)
+		}
+		f, err := n.compile(re.Simplify())
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i, err)
+		}
+		n.epsilon(start, f.start)
+		n.states[f.end].accept = i
+	}
+
+	d := &DFA{rules: rules}
+	indexes := make(map[string]int)
+	var sets [][]int
+	add := func(set []int) int {
+		set = n.closure(set)
+		slices.Sort(set)
+		key := setKey(set)
+		if i, ok := indexes[key]; ok {
+			return i
+		}
+		accept := -1
+		for _, s := range set {
+			if a := n.states[s].accept; a >= 0 && (accept < 0 || a < accept) {
+				accept = a
+			}
+		}
+		indexes[key] = len(d.states)
+		d.states = append(d.states, state{accept: accept})
+		sets = append(sets, set)
+		return len(d.states) - 1
+	}
+
+	add([]int{start})
+	if a := d.states[0].accept; a >= 0 {
+		return nil, fmt.Errorf("rule %d: matches the empty string", a)
+	}
+	for i := 0; i < len(sets); i++ {
+		d.states[i].trans = d.transitions(n, sets[i], add)
+	}
+	return d, nil
+}
+
+// transitions returns the transitions of the DFA state whose NFA states are set. add returns the index of the DFA
+// state of a set of NFA states.
+func (d *DFA) transitions(n *nfa, set []int, add func([]int) int) []transition {
+	// the bounds of the intervals of runes that lead to the same NFA states
+	var points []rune
+	for _, s := range set {
+		ranges := n.states[s].ranges
+		for i := 0; i < len(ranges); i += 2 {
+			points = append(points, ranges[i], ranges[i+1]+1)
+		}
+	}
+	slices.Sort(points)
+	points = slices.Compact(points)
+
+	var trans []transition
+	for i := 0; i+1 < len(points); i++ {
+		lo, hi := points[i], points[i+1]-1
+		var targets []int
+		for _, s := range set {
+			if inRanges(n.states[s].ranges, lo) {
+				targets = append(targets, n.states[s].next)
+			}
+		}
+		if len(targets) == 0 {
+			continue
+		}
+		next := add(targets)
+		if l := len(trans); l > 0 && trans[l-1].next == next && trans[l-1].hi+1 == lo {
+			trans[l-1].hi = hi
+		} else {
+			trans = append(trans, transition{lo, hi, next})
+		}
+	}
+	return trans
+}
+
+// inRanges reports whether r is in ranges.
+func inRanges(ranges []rune, r rune) bool {
+	for i := 0; i < len(ranges); i += 2 {
+		if ranges[i] <= r && r <= ranges[i+1] {
+			return true
+		}
+	}
+	return false
+}
+
+// setKey returns a string that identifies the sorted set.
+func setKey(set []int) string {
+	var sb strings.Builder
+	for _, s := range set {
+		sb.WriteString(strconv.Itoa(s))
+		sb.WriteByte(',')
+	}
+	return sb.String()
+}
+
+// step returns the state reached from the state s by r, or -1 if there is no transition.
+func (d *DFA) step(s int, r rune) int {
+	trans := d.states[s].trans
+	i, found := slices.BinarySearchFunc(trans, r, func(t transition, r rune) int {
+		if t.hi < r {
+			return -1
+		} else if t.lo > r {
+			return 1
+		}
+		return 0
+	})
+	if !found {
+		return -1
+	}
+	return trans[i].next
+}
+
+// Scanner reads tokens from a rem.File using a DFA.
+type Scanner struct {
+	d *DFA
+	f rem.File
+}
+
+// NewScanner creates a new Scanner that reads from f starting at its current offset.
+func (d *DFA) NewScanner(f rem.File) *Scanner {
+	return &Scanner{d: d, f: f}
+}
+
+// Next returns the next token. It returns the longest token that matches a rule. If no rule matches, it returns a token
+// of type lex.Error that contains the next rune. At the end of the input, it returns a token of type lex.EOFToken. The
+// input before the end of the returned token is marked as consumed.
+func (s *Scanner) Next() lex.Token {
+	for {
+		t, ok := s.scan()
+		s.f.Consumed(t.End)
+		if ok {
+			return t
+		}
+	}
+}
+
+// scan reads a token. ok is false if the token was discarded by a action.
+func (s *Scanner) scan() (t lex.Token, ok bool) {
+	start := s.f.Offset()
+	var text []byte
+	// n is the number of runes read, and accepted is the number of runes of the longest token
+	n, accepted := 0, 0
+	textLen, rule := 0, -1
+	for st := 0; ; {
+		r, eof := s.f.Next()
+		if eof {
+			break
+		}
+		st = s.d.step(st, r)
+		if st < 0 {
+			s.f.Previous()
+			break
+		}
+		n++
+		text = utf8.AppendRune(text, r)
+		if a := s.d.states[st].accept; a >= 0 {
+			accepted, textLen, rule = n, len(text), a
+		}
+	}
+	// backs off to the last accepting state
+	for ; n > accepted; n-- {
+		s.f.Previous()
+	}
+
+	if rule < 0 {
+		r, eof := s.f.Next()
+		if eof {
+			return lex.Token{Type: lex.EOFToken, Start: start, End: start}, true
+		}
+		return lex.Token{Type: lex.Error, Start: start, End: s.f.Offset(), Value: string(r)}, true
+	}
+
+	t = lex.Token{Type: s.d.rules[rule].Type, Start: start, End: s.f.Offset(), Value: string(text[:textLen])}
+	if action := s.d.rules[rule].Action; action != nil {
+		return t, action(&t)
+	}
+	return t, true
+}
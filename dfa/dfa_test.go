@@ -0,0 +1,105 @@
+package dfa
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/joaobnv/rem"
+	"github.com/joaobnv/rem/lex"
+)
+
+const (
+	number lex.TokenType = iota
+	identifier
+	keyword
+	operator
+	comment
+)
+
+// rules are the rules used in the tests.
+var rules = []Rule{
+	{Pattern: `[0-9]+(\.[0-9]+)?([eE][+-]?[0-9]+)?`, Type: number},
+	{Pattern: `(?i)if|else`, Type: keyword},
+	{Pattern: `\pL(\pL|[0-9_])*`, Type: identifier},
+	{Pattern: `<=|<|=|\+\+|\+`, Type: operator},
+	{Pattern: `/\*([^*]|\*+[^*/])*\*+/`, Type: comment, Action: func(t *lex.Token) bool { return false }},
+	{Pattern: `\s+`, Action: func(t *lex.Token) bool { return false }},
+}
+
+// TestScanner tests the Scanner.
+func TestScanner(t *testing.T) {
+	d, err := Compile(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := "IF ifx<=1.5e+3 /* c */ ação++1.5e+ #"
+	expected := []lex.Token{
+		{Type: keyword, Start: 0, End: 2, Value: "IF"},
+		{Type: identifier, Start: 3, End: 6, Value: "ifx"},
+		{Type: operator, Start: 6, End: 8, Value: "<="},
+		{Type: number, Start: 8, End: 14, Value: "1.5e+3"},
+		{Type: identifier, Start: 23, End: 29, Value: "ação"},
+		{Type: operator, Start: 29, End: 31, Value: "++"},
+		{Type: number, Start: 31, End: 34, Value: "1.5"},
+		{Type: identifier, Start: 34, End: 35, Value: "e"},
+		{Type: operator, Start: 35, End: 36, Value: "+"},
+		{Type: lex.Error, Start: 37, End: 38, Value: "#"},
+		{Type: lex.EOFToken, Start: 38, End: 38},
+	}
+
+	files := []rem.File{
+		rem.NewFile([]byte(input)),
+		rem.NewFileFromString(input),
+		rem.NewFileFromReader(bufio.NewReader(strings.NewReader(input)), 4, 1<<10, "."),
+	}
+	for _, f := range files {
+		s := d.NewScanner(f)
+		for _, et := range expected {
+			if tok := s.Next(); tok != et {
+				t.Errorf("%T: expected %v, got %v", f, et, tok)
+			}
+		}
+		f.Close()
+	}
+}
+
+// TestScannerComments tests if each comment ends at its first "*/".
+func TestScannerComments(t *testing.T) {
+	d, err := Compile(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := d.NewScanner(rem.NewFileFromString("/* a */ x /* b **/ y"))
+	expected := []lex.Token{
+		{Type: identifier, Start: 8, End: 9, Value: "x"},
+		{Type: identifier, Start: 19, End: 20, Value: "y"},
+		{Type: lex.EOFToken, Start: 20, End: 20},
+	}
+	for _, et := range expected {
+		if tok := s.Next(); tok != et {
+			t.Errorf("expected %v, got %v", et, tok)
+		}
+	}
+}
+
+// TestCompileErrors tests the errors returned by Compile.
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		pattern string
+		err     string
+	}{
+		{`a(`, "rule 1: error parsing regexp: missing closing ): `a(`"},
+		{`^a`, "rule 1: unsupported regular expression operator BeginText"},
+		{`a*`, "rule 1: matches the empty string"},
+		{`/\*(.|\n)*?\*/`, "rule 1: unsupported non-greedy regular expression operator Star"},
+	}
+	for _, test := range tests {
+		_, err := Compile([]Rule{{Pattern: "b"}, {Pattern: test.pattern}})
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%s: expected error %q, got %v", test.pattern, test.err, err)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package dfa
+
+import (
+	"fmt"
+	"regexp/syntax"
+	"unicode"
+)
+
+// nfaState is a state of a Thompson NFA. A state has epsilon transitions, or a transition labeled by rune ranges,
+// or none.
+type nfaState struct {
+	// eps are the targets of the epsilon transitions.
+	eps []int
+	// ranges are pairs of inclusive bounds of the runes that label the transition to next.
+	ranges []rune
+	// next is the target of the transition labeled by ranges.
+	next int
+	// accept is the index of the rule accepted by the state, or -1 if the state is not accepting.
+	accept int
+}
+
+// nfa is a Thompson NFA for a set of rules.
+type nfa struct {
+	states []nfaState
+}
+
+// frag is a fragment of a NFA with one start state and one end state, the end state has no transitions.
+type frag struct {
+	start, end int
+}
+
+// newState adds a new state to n and returns its index.
+func (n *nfa) newState() int {
+	n.states = append(n.states, nfaState{next: -1, accept: -1})
+	return len(n.states) - 1
+}
+
+// epsilon adds a epsilon transition from the state from to the state to.
+func (n *nfa) epsilon(from, to int) {
+	n.states[from].eps = append(n.states[from].eps, to)
+}
+
+// class creates a fragment that matches one rune in ranges.
+func (n *nfa) class(ranges []rune) frag {
+	f := frag{n.newState(), n.newState()}
+	n.states[f.start].ranges = ranges
+	n.states[f.start].next = f.end
+	return f
+}
+
+// empty creates a fragment that matches the empty string.
+func (n *nfa) empty() frag {
+	f := frag{n.newState(), n.newState()}
+	n.epsilon(f.start, f.end)
+	return f
+}
+
+// compile creates a fragment that matches re.
+func (n *nfa) compile(re *syntax.Regexp) (frag, error) {
+	switch re.Op {
+	case syntax.OpEmptyMatch:
+		return n.empty(), nil
+	case syntax.OpLiteral:
+		f := n.empty()
+		f.end = f.start
+		for _, r := range re.Rune {
+			ranges := []rune{r, r}
+			if re.Flags&syntax.FoldCase != 0 {
+				ranges = foldRanges(r)
+			}
+			c := n.class(ranges)
+			n.epsilon(f.end, c.start)
+			f.end = c.end
+		}
+		return f, nil
+	case syntax.OpCharClass:
+		return n.class(re.Rune), nil
+	case syntax.OpAnyCharNotNL:
+		return n.class([]rune{0, '\n' - 1, '\n' + 1, unicode.MaxRune}), nil
+	case syntax.OpAnyChar:
+		return n.class([]rune{0, unicode.MaxRune}), nil
+	case syntax.OpCapture:
+		return n.compile(re.Sub[0])
+	case syntax.OpConcat:
+		f := n.empty()
+		f.end = f.start
+		for _, sub := range re.Sub {
+			s, err := n.compile(sub)
+			if err != nil {
+				return frag{}, err
+			}
+			n.epsilon(f.end, s.start)
+			f.end = s.end
+		}
+		return f, nil
+	case syntax.OpAlternate:
+		f := frag{n.newState(), n.newState()}
+		for _, sub := range re.Sub {
+			s, err := n.compile(sub)
+			if err != nil {
+				return frag{}, err
+			}
+			n.epsilon(f.start, s.start)
+			n.epsilon(s.end, f.end)
+		}
+		return f, nil
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest:
+		// the scanner takes the longest match, so a non-greedy operator would behave as a greedy one.
+		if re.Flags&syntax.NonGreedy != 0 {
+			return frag{}, fmt.Errorf("unsupported non-greedy regular expression operator %v", re.Op)
+		}
+		s, err := n.compile(re.Sub[0])
+		if err != nil {
+			return frag{}, err
+		}
+		f := frag{n.newState(), n.newState()}
+		n.epsilon(f.start, s.start)
+		n.epsilon(s.end, f.end)
+		if re.Op != syntax.OpPlus {
+			n.epsilon(f.start, f.end)
+		}
+		if re.Op != syntax.OpQuest {
+			n.epsilon(s.end, s.start)
+		}
+		return f, nil
+	}
+	return frag{}, fmt.Errorf("unsupported regular expression operator %v", re.Op)
+}
+
+// foldRanges returns the ranges of the runes that are equivalent to r under simple case folding.
+func foldRanges(r rune) []rune {
+	ranges := []rune{r, r}
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		ranges = append(ranges, f, f)
+	}
+	return ranges
+}
+
+// closure adds to set the states reachable from the states in set by epsilon transitions.
+func (n *nfa) closure(set []int) []int {
+	in := make(map[int]bool, len(set))
+	stack := append([]int(nil), set...)
+	set = set[:0]
+	for len(stack) > 0 {
+		s := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if in[s] {
+			continue
+		}
+		in[s] = true
+		set = append(set, s)
+		stack = append(stack, n.states[s].eps...)
+	}
+	return set
+}
@@ -0,0 +1,203 @@
+package rem
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// FollowFile is a File that follows a file that is still being written, like tail -f. When Next reaches the end of the
+// file, it waits for the file to grow instead of returning EOF. It returns EOF only after Stop is called and the end of
+// the file is reached.
+//
+// FollowFile detects when the file is truncated, then it continues from the start of the file, and when the file is
+// rotated, then it reads the old file until its end and continues from the start of the new file. In both cases the
+// offsets restart from 0. Next handles these events silently, NextStatus reports them.
+//
+// Only Next and NextStatus follow the file. The other methods, like NextByte, ReadBytes, SkipUntil and IndexString,
+// see the bytes written so far and treat their end as the EOF. The Files returned by Fork do not follow the file, they
+// keep reading the file that was current when they were created, which stays open until they are closed.
+type FollowFile interface {
+	File
+
+	// NextStatus is like Next, but it does not wait. At the end of the file it reports NeedMoreInput, or EOF if Stop
+	// was called, and the offset remains unchanged. It reports Truncated and Rotated once for each event.
+	NextStatus() (r rune, status Status)
+
+	// Stop makes the end of the file a true end. The waiting calls to Next return EOF.
+	Stop()
+}
+
+// followFile implements FollowFile using a readerAt over the file.
+type followFile struct {
+	*readerAt
+	// path is the name of the followed file.
+	path string
+	// file is the file currently read.
+	file *os.File
+	// c closes file when the followFile and the forks that read file are closed.
+	c *closers
+	// closed indicates whether Close was called.
+	closed bool
+	// poll is the interval between the checks for growth.
+	poll time.Duration
+	// ctx is the context of the File, or nil.
+	ctx context.Context
+	// stop is closed by Stop.
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewFollowFile opens the file named path and returns a FollowFile that checks for growth every poll interval. The
+// option WithContext makes the waiting calls to Next panic with an error that wraps ErrCanceled when the context is done.
+func NewFollowFile(path string, poll time.Duration, opts ...Option) (FollowFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	o := newOptions(opts)
+	return &followFile{
+		readerAt: newReaderAt(file),
+		path:     path,
+		file:     file,
+		c:        &closers{list: []io.Closer{file}, refs: 1},
+		poll:     poll,
+		ctx:      o.ctx,
+		stop:     make(chan struct{}),
+	}, nil
+}
+
+// Next returns the rune at the current offset. At the end of the file, it waits for the file to grow, and it returns
+// eof = true only if Stop was called. It panics on error.
+func (ff *followFile) Next() (r rune, eof bool) {
+	for {
+		r, status := ff.NextStatus()
+		switch status {
+		case OK:
+			return r, false
+		case EOF:
+			return 0, true
+		case NeedMoreInput:
+			ff.wait()
+		}
+	}
+}
+
+// wait waits for the poll interval, or until Stop is called. It panics if the context is done.
+func (ff *followFile) wait() {
+	var done <-chan struct{}
+	if ff.ctx != nil {
+		done = ff.ctx.Done()
+	}
+	timer := time.NewTimer(ff.poll)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ff.stop:
+	case <-done:
+		panic(canceledError(ff.ctx))
+	}
+}
+
+// NextStatus is like Next, but it does not wait.
+func (ff *followFile) NextStatus() (r rune, status Status) {
+	stopped := ff.stopped()
+	p := make([]byte, utf8.UTFMax)
+	n, err := ff.ra.ReadAt(p, ff.offset)
+	if err != nil && err != io.EOF {
+		panic(err)
+	}
+	if utf8.FullRune(p[:n]) || (n > 0 && stopped) {
+		r, _ = ff.readerAt.Next()
+		return r, OK
+	}
+
+	fi, err := ff.file.Stat()
+	if err != nil {
+		panic(err)
+	}
+	if fi.Size() < ff.offset {
+		ff.offset = 0
+		return 0, Truncated
+	}
+	if n == 0 {
+		if rotated, err := ff.reopen(fi); err != nil {
+			panic(err)
+		} else if rotated {
+			return 0, Rotated
+		}
+	}
+	if stopped {
+		return 0, EOF
+	}
+	return 0, NeedMoreInput
+}
+
+// reopen opens the file named path if it is not the file currently read. fi is the information of the file currently read.
+func (ff *followFile) reopen(fi os.FileInfo) (rotated bool, err error) {
+	newFi, err := os.Stat(ff.path)
+	if errors.Is(err, os.ErrNotExist) {
+		// the new file was not created yet
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if os.SameFile(fi, newFi) {
+		return false, nil
+	}
+	file, err := os.Open(ff.path)
+	if err != nil {
+		return false, err
+	}
+	ff.release()
+	ff.file = file
+	ff.c = &closers{list: []io.Closer{file}, refs: 1}
+	ff.ra = file
+	ff.offset = 0
+	return true, nil
+}
+
+// release releases the file currently read, it is closed if no fork reads it.
+func (ff *followFile) release() error {
+	ff.c.mu.Lock()
+	defer ff.c.mu.Unlock()
+	return ff.c.release()
+}
+
+// Fork returns a File that reads the file currently read, with an independent offset initially equal to the current
+// offset of ff. The File does not follow the file.
+func (ff *followFile) Fork() File {
+	ff.c.mu.Lock()
+	defer ff.c.mu.Unlock()
+	ff.c.refs++
+	return &closingFile{File: ff.readerAt.Fork(), c: ff.c}
+}
+
+// Stop makes the end of the file a true end.
+func (ff *followFile) Stop() {
+	ff.stopOnce.Do(func() { close(ff.stop) })
+}
+
+// stopped reports whether Stop was called.
+func (ff *followFile) stopped() bool {
+	select {
+	case <-ff.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close closes the file, unless a fork still reads it.
+func (ff *followFile) Close() error {
+	ff.Stop()
+	if ff.closed {
+		return nil
+	}
+	ff.closed = true
+	return ff.release()
+}
@@ -0,0 +1,137 @@
+package rem
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// appendFile appends data to the file named name.
+func appendFile(t *testing.T, name, data string) {
+	t.Helper()
+	f, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err = f.WriteString(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// expectStatus checks the result of NextStatus.
+func expectStatus(t *testing.T, f FollowFile, er rune, es Status) {
+	t.Helper()
+	if r, status := f.NextStatus(); r != er || status != es {
+		t.Errorf("expected %q (%v), got %q (%v)", er, es, r, status)
+	}
+}
+
+// TestFollowFile tests the FollowFile.
+func TestFollowFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "log")
+	appendFile(t, name, "a\xc3")
+	f, err := NewFollowFile(name, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	expectStatus(t, f, 'a', OK)
+	// incomplete rune
+	expectStatus(t, f, 0, NeedMoreInput)
+
+	time.AfterFunc(20*time.Millisecond, func() { appendFile(t, name, "\xa9b") })
+	if r, eof := f.Next(); eof || r != 'é' {
+		t.Errorf("expected 'é', got %q (eof = %t)", r, eof)
+	}
+	expectStatus(t, f, 'b', OK)
+	expectStatus(t, f, 0, NeedMoreInput)
+
+	// truncation
+	if err = os.Truncate(name, 0); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, name, "c")
+	expectStatus(t, f, 0, Truncated)
+	expectStatus(t, f, 'c', OK)
+
+	// rotation
+	if err = os.Rename(name, name+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, name+".1", "d")
+	expectStatus(t, f, 'd', OK)
+	expectStatus(t, f, 0, NeedMoreInput)
+	appendFile(t, name, "e")
+	expectStatus(t, f, 0, Rotated)
+	if f.Offset() != 0 {
+		t.Errorf("expected offset = 0, got %d", f.Offset())
+	}
+	if r, _ := f.Next(); r != 'e' {
+		t.Errorf("expected 'e', got %q", r)
+	}
+
+	time.AfterFunc(20*time.Millisecond, f.Stop)
+	if _, eof := f.Next(); !eof {
+		t.Errorf("expected EOF")
+	}
+	expectStatus(t, f, 0, EOF)
+}
+
+// TestFollowFileFork tests if a fork keeps reading its file after a rotation and after the FollowFile is closed.
+func TestFollowFileFork(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "log")
+	appendFile(t, name, "ab")
+	f, err := NewFollowFile(name, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fork := f.Fork()
+	defer fork.Close()
+
+	if err = os.Rename(name, name+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, name, "c")
+	expectStatus(t, f, 'a', OK)
+	expectStatus(t, f, 'b', OK)
+	expectStatus(t, f, 0, Rotated)
+	expectStatus(t, f, 'c', OK)
+	if r, _ := fork.Next(); r != 'a' {
+		t.Errorf("expected 'a', got %q", r)
+	}
+
+	if err = f.Close(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if r, _ := fork.Next(); r != 'b' {
+		t.Errorf("expected 'b', got %q", r)
+	}
+	if _, eof := fork.Next(); !eof {
+		t.Errorf("expected EOF")
+	}
+}
+
+// TestFollowFileContext tests if a waiting Next panics when the context is done.
+func TestFollowFileContext(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "log")
+	appendFile(t, name, "")
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	f, err := NewFollowFile(name, time.Millisecond, WithContext(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	expectCanceled(t, context.DeadlineExceeded, func() { f.Next() })
+}
+
+// TestNewFollowFileError tests if NewFollowFile returns the error of os.Open.
+func TestNewFollowFileError(t *testing.T) {
+	if _, err := NewFollowFile(filepath.Join(t.TempDir(), "missing"), time.Millisecond); !os.IsNotExist(err) {
+		t.Errorf("expected a not exist error, got %v", err)
+	}
+}
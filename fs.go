@@ -0,0 +1,66 @@
+package rem
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+)
+
+// Namer is implemented by the Files that know the name of their input, for position reporting.
+type Namer interface {
+	// Name returns the name of the input.
+	Name() string
+}
+
+// Name returns the name of the input of f, or the empty string if f does not implement Namer.
+func Name(f File) string {
+	if n, ok := f.(Namer); ok {
+		return n.Name()
+	}
+	return ""
+}
+
+// namedFile is a File that knows the name of its input.
+type namedFile struct {
+	File
+	// name is the name of the input.
+	name string
+}
+
+// Name returns the name of the input.
+func (nf *namedFile) Name() string {
+	return nf.name
+}
+
+// Fork returns a fork of the File with the same name.
+func (nf *namedFile) Fork() File {
+	return &namedFile{File: nf.File.Fork(), name: nf.name}
+}
+
+// OpenFS opens the file named name in fsys and returns a File that reads it. The File uses the io.ReaderAt backend if
+// the fs.File implements io.ReaderAt, the io.ReadSeeker backend if it implements io.Seeker, and otherwise the io.Reader
+// backend with the default directory for temporary files. The File implements Namer. The fs.File is closed when the
+// File and all its forks are closed.
+func OpenFS(fsys fs.FS, name string, opts ...Option) (File, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		file.Close()
+		return nil, &fs.PathError{Op: "open", Path: name, Err: errors.New("is a directory")}
+	}
+
+	var f File
+	if ra, ok := file.(io.ReaderAt); ok {
+		f = withContext(newOptions(opts).ctx, newReaderAt(ra))
+	} else {
+		f = NewFileFromReader(file, openMemLimit, openDiskLimit, "", opts...)
+	}
+	return &namedFile{File: newClosingFile(f, file), name: name}, nil
+}
@@ -0,0 +1,72 @@
+package rem
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+// methodsFS is a fs.FS whose files implement only the methods of fs.File, and io.Seeker if seeker is true.
+type methodsFS struct {
+	fs.FS
+	seeker bool
+}
+
+// Open opens the file named name.
+func (mfs methodsFS) Open(name string) (fs.File, error) {
+	file, err := mfs.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if mfs.seeker {
+		return struct {
+			fs.File
+			io.Seeker
+		}{file, file.(io.Seeker)}, nil
+	}
+	return struct{ fs.File }{file}, nil
+}
+
+// TestOpenFS tests the backends chosen by OpenFS and the name of the Files.
+func TestOpenFS(t *testing.T) {
+	fsys := fstest.MapFS{"dir/a.txt": {Data: []byte("abç")}}
+	tests := []struct {
+		fsys    fs.FS
+		backend File
+	}{
+		{fsys, &readerAt{}},
+		{methodsFS{fsys, true}, &seeker{}},
+		{methodsFS{fsys, false}, &reader{}},
+	}
+	for _, test := range tests {
+		f, err := OpenFS(test.fsys, "dir/a.txt")
+		if err != nil {
+			t.Fatalf("%T: unexpected error %v", test.backend, err)
+		}
+		if backend := f.(*namedFile).File.(*closingFile).File; fmt.Sprintf("%T", backend) != fmt.Sprintf("%T", test.backend) {
+			t.Errorf("expected a %T, got %T", test.backend, backend)
+		}
+		if got := readString(f); got != "abç" {
+			t.Errorf("%T: unexpected content %q", test.backend, got)
+		}
+		if name := Name(f.Fork()); name != "dir/a.txt" {
+			t.Errorf("%T: unexpected name %q", test.backend, name)
+		}
+		if err := f.Close(); err != nil {
+			t.Errorf("%T: unexpected error %v", test.backend, err)
+		}
+	}
+
+	if _, err := OpenFS(fsys, "b.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected %v, got %v", fs.ErrNotExist, err)
+	}
+	if _, err := OpenFS(fsys, "dir"); err == nil {
+		t.Errorf("error expected")
+	}
+	if name := Name(NewFileFromString("abc")); name != "" {
+		t.Errorf("unexpected name %q", name)
+	}
+}
@@ -0,0 +1,162 @@
+package rem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// httpBlockSize is the size of the blocks requested by the Files returned by NewHTTPFile.
+	httpBlockSize = 1 << 16
+	// httpMaxBlocks is the maximum number of blocks cached by the Files returned by NewHTTPFile.
+	httpMaxBlocks = 16
+	// defaultRetries is the number of retries of a failed request when it is not configured.
+	defaultRetries = 3
+	// retryDelay is the delay before the first retry. It doubles on each retry.
+	retryDelay = 50 * time.Millisecond
+)
+
+// WithHTTPClient makes NewHTTPFile use client for the requests instead of http.DefaultClient.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.client = client
+	}
+}
+
+// WithRetries makes NewHTTPFile retry the requests that fail with a network error, a 5xx status or a body that is cut
+// off up to retries times.
+func WithRetries(retries int) Option {
+	return func(o *options) {
+		o.retries = &retries
+	}
+}
+
+// NewHTTPFile returns a File that reads the resource at url with HTTP range requests. The bounds of the resource are
+// given by the Content-Length of a HEAD request, and the server must support range requests. The resource is requested
+// in blocks, and the blocks used most recently are cached. The File implements Namer with url as the name. The
+// option WithContext also cancels the requests.
+func NewHTTPFile(url string, opts ...Option) (File, error) {
+	o := newOptions(opts)
+	hra := &httpReaderAt{url: url, client: http.DefaultClient, retries: defaultRetries, ctx: o.ctx}
+	if o.client != nil {
+		hra.client = o.client
+	}
+	if o.retries != nil {
+		hra.retries = *o.retries
+	}
+	if hra.ctx == nil {
+		hra.ctx = context.Background()
+	}
+
+	resp, err := hra.do(http.MethodHead, "")
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HEAD %s: %s", url, resp.Status)
+	}
+	if resp.ContentLength < 0 {
+		return nil, fmt.Errorf("HEAD %s: unknown content length", url)
+	}
+	hra.size = resp.ContentLength
+	return &namedFile{File: withContext(o.ctx, newReaderAt(newBlockCache(hra, httpBlockSize, httpMaxBlocks))), name: url}, nil
+}
+
+// httpReaderAt is a io.ReaderAt over a HTTP resource.
+type httpReaderAt struct {
+	// url is the URL of the resource.
+	url string
+	// size is the size of the resource.
+	size int64
+	// client does the requests.
+	client *http.Client
+	// retries is the number of retries of a failed request.
+	retries int
+	// ctx is the context of the requests.
+	ctx context.Context
+}
+
+// ReadAt implements io.ReaderAt with a range request. The request is retried if the body is cut off.
+func (hra *httpReaderAt) ReadAt(p []byte, off int64) (n int, err error) {
+	if off >= hra.size {
+		return 0, io.EOF
+	}
+	end := min(off+int64(len(p)), hra.size)
+	rng := "bytes=" + strconv.FormatInt(off, 10) + "-" + strconv.FormatInt(end-1, 10)
+	err = hra.retry(func() (bool, error) {
+		resp, retry, err := hra.send(http.MethodGet, rng)
+		if err != nil {
+			return retry, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusPartialContent {
+			return false, fmt.Errorf("GET %s: %s", hra.url, resp.Status)
+		}
+		n, err = io.ReadFull(resp.Body, p[:end-off])
+		return err != nil, err
+	})
+	if err != nil {
+		return n, err
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// do does a request, retrying it if it fails with a network error or a 5xx status. rng is the Range header, it is
+// not sent if it is the empty string.
+func (hra *httpReaderAt) do(method, rng string) (resp *http.Response, err error) {
+	err = hra.retry(func() (retry bool, err error) {
+		resp, retry, err = hra.send(method, rng)
+		return
+	})
+	return
+}
+
+// send does a request once. A network error or a 5xx status is returned as an error that can be retried.
+func (hra *httpReaderAt) send(method, rng string) (resp *http.Response, retry bool, err error) {
+	req, err := http.NewRequestWithContext(hra.ctx, method, hra.url, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	if rng != "" {
+		req.Header.Set("Range", rng)
+	}
+	resp, err = hra.client.Do(req)
+	if err != nil {
+		return nil, true, err
+	}
+	if resp.StatusCode >= 500 {
+		resp.Body.Close()
+		return nil, true, fmt.Errorf("%s %s: %s", method, hra.url, resp.Status)
+	}
+	return resp, false, nil
+}
+
+// retry calls f until it succeeds, it returns an error that can not be retried, the retries are exhausted or the
+// context is done. The delay between the calls doubles on each retry.
+func (hra *httpReaderAt) retry(f func() (retry bool, err error)) error {
+	delay := retryDelay
+	for i := 0; ; i++ {
+		retry, err := f()
+		if err == nil || !retry || i == hra.retries || hra.ctx.Err() != nil {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-hra.ctx.Done():
+			timer.Stop()
+			return errors.Join(err, hra.ctx.Err())
+		}
+		delay *= 2
+	}
+}
@@ -0,0 +1,156 @@
+package rem
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// rangeServer is a HTTP handler that serves data with range requests, fails the first failures requests and cuts off
+// the body of the first cuts GET requests that do not fail.
+type rangeServer struct {
+	mu       sync.Mutex
+	data     string
+	failures int
+	cuts     int
+	requests int
+}
+
+// ServeHTTP serves the request.
+func (rs *rangeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rs.mu.Lock()
+	rs.requests++
+	fail := rs.failures > 0
+	rs.failures--
+	cut := !fail && r.Method == http.MethodGet && rs.cuts > 0
+	if cut {
+		rs.cuts--
+	}
+	rs.mu.Unlock()
+	if fail {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	if cut {
+		w.Header().Set("Content-Length", strconv.Itoa(len(rs.data)))
+		w.WriteHeader(http.StatusPartialContent)
+		io.WriteString(w, rs.data[:len(rs.data)/2])
+		return
+	}
+	http.ServeContent(w, r, "", time.Time{}, strings.NewReader(rs.data))
+}
+
+// TestHTTPFile tests the reading of a HTTP resource with range requests and the cache of the blocks.
+func TestHTTPFile(t *testing.T) {
+	data := strings.Repeat("0123456789abcdeç", httpBlockSize/4)
+	rs := &rangeServer{data: data, failures: 2}
+	server := httptest.NewServer(rs)
+	defer server.Close()
+
+	f, err := NewHTTPFile(server.URL, WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	defer f.Close()
+	if name := Name(f); name != server.URL {
+		t.Errorf("unexpected name %q", name)
+	}
+	if got := readString(f); got != data {
+		t.Errorf("unexpected content")
+	}
+	if i := f.LastIndexString("0123"); i != int64(len(data)-17) {
+		t.Errorf("unexpected index %d", i)
+	}
+	// 2 failures, 1 HEAD and 5 blocks.
+	if rs.requests != 8 {
+		t.Errorf("expected 8 requests, got %d", rs.requests)
+	}
+}
+
+// TestHTTPFileCutOff tests if the range requests whose body is cut off are retried.
+func TestHTTPFileCutOff(t *testing.T) {
+	rs := &rangeServer{data: "0123456789", cuts: 1}
+	server := httptest.NewServer(rs)
+	defer server.Close()
+	f, err := NewHTTPFile(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	defer f.Close()
+	if got := readString(f); got != rs.data {
+		t.Errorf("unexpected content %q", got)
+	}
+	// 1 HEAD, 1 cut off and 1 block.
+	if rs.requests != 3 {
+		t.Errorf("expected 3 requests, got %d", rs.requests)
+	}
+
+	rs = &rangeServer{data: "0123456789", cuts: 2}
+	server2 := httptest.NewServer(rs)
+	defer server2.Close()
+	f2, err := NewHTTPFile(server2.URL, WithRetries(1))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	defer f2.Close()
+	defer func() {
+		if err, _ := recover().(error); err != io.ErrUnexpectedEOF {
+			t.Errorf("expected a panic with %v, got %v", io.ErrUnexpectedEOF, err)
+		}
+	}()
+	f2.Next()
+}
+
+// TestHTTPFileError tests the errors of the requests.
+func TestHTTPFileError(t *testing.T) {
+	rs := &rangeServer{data: "abc", failures: 2}
+	server := httptest.NewServer(rs)
+	defer server.Close()
+	if _, err := NewHTTPFile(server.URL, WithRetries(1)); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("expected an error with the status 503, got %v", err)
+	}
+
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+	if _, err := NewHTTPFile(notFound.URL); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("expected an error with the status 404, got %v", err)
+	}
+
+	noRanges := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "3")
+		w.Write([]byte("abc"))
+	}))
+	defer noRanges.Close()
+	f, err := NewHTTPFile(noRanges.URL)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	defer f.Close()
+	defer func() {
+		err, _ := recover().(error)
+		if err == nil || !strings.Contains(err.Error(), "200") {
+			t.Errorf("expected a panic with the status 200, got %v", err)
+		}
+	}()
+	f.Next()
+}
+
+// TestHTTPReaderAtBounds tests if the reads are bounded by the Content-Length.
+func TestHTTPReaderAtBounds(t *testing.T) {
+	server := httptest.NewServer(&rangeServer{data: "abcdef"})
+	defer server.Close()
+	f, err := NewHTTPFile(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	defer f.Close()
+	if p, eof := f.ReadBytes(10); !eof || !bytes.Equal(p, []byte("abcdef")) {
+		t.Errorf("unexpected result %q, %v", p, eof)
+	}
+}
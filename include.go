@@ -0,0 +1,373 @@
+package rem
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// IncludeFile is a File that expands includes: Push inserts another input at the current offset, and the outer input
+// continues when the inserted one reaches EOF. The offsets are the offsets in the expanded input.
+type IncludeFile interface {
+	File
+	Namer
+
+	// Push makes f, named name, the input at the current offset. It returns an error if the maximum depth of includes
+	// is reached, or if the current offset is before the last include boundary read. Close closes f.
+	Push(name string, f File) error
+
+	// Position returns the position at the current offset, with the chain of includes.
+	Position() Position
+
+	// Depth returns the number of includes that contain the current offset.
+	Depth() int
+}
+
+// Position is a position in one of the inputs of an IncludeFile.
+type Position struct {
+	// Name is the name of the input.
+	Name string
+	// Line is the line number, starting at 1.
+	Line int
+	// Offset is the offset in the input.
+	Offset int64
+	// IncludedFrom is the position of the include of the input, or nil if the input is not included.
+	IncludedFrom *Position
+}
+
+// String returns the position in the form "name:line", followed by the chain of includes.
+func (p Position) String() string {
+	var sb strings.Builder
+	sb.WriteString(p.Name + ":" + strconv.Itoa(p.Line))
+	for from := p.IncludedFrom; from != nil; from = from.IncludedFrom {
+		sb.WriteString(", included from " + from.Name + ":" + strconv.Itoa(from.Line))
+	}
+	return sb.String()
+}
+
+// frame is an input of an includeFile.
+type frame struct {
+	// name is the name of the input.
+	name string
+	// f is the input.
+	f File
+	// line is the line number at the current offset of f.
+	line int
+	// parent is the input that includes this, or nil.
+	parent *frame
+	// from is the position of the include, or nil.
+	from *Position
+	// depth is the number of includes that contain the input.
+	depth int
+}
+
+// segment is a range of an input in the expanded input.
+type segment struct {
+	// frame is the input.
+	frame *frame
+	// start is the offset of the start of the segment in the input.
+	start int64
+	// end is the offset of the end of the segment in the input, or -1 if it is the last segment.
+	end int64
+	// pos is the offset of the start of the segment in the expanded input.
+	pos int64
+}
+
+// includeFile is an IncludeFile.
+type includeFile struct {
+	// segs are the segments of the expanded input read so far. The consumed segments are removed.
+	segs []segment
+	// cur is the index of the current segment. The inputs are at the offsets that correspond to the current offset
+	// in each segment.
+	cur int
+	// maxDepth is the maximum depth of includes.
+	maxDepth int
+	// tx are the open transactions.
+	tx transactions
+}
+
+// NewIncludeFile returns an IncludeFile that reads f, named name, and allows up to maxDepth nested includes. The line
+// numbers of the positions start at the current offset of f.
+func NewIncludeFile(name string, f File, maxDepth int) IncludeFile {
+	fr := &frame{name: name, f: f, line: 1}
+	return &includeFile{segs: []segment{{frame: fr, start: f.Offset(), end: -1}}, maxDepth: maxDepth}
+}
+
+// Push makes f, named name, the input at the current offset.
+func (in *includeFile) Push(name string, f File) error {
+	if in.cur != len(in.segs)-1 {
+		return errors.New("push before the last include boundary")
+	}
+	s := &in.segs[in.cur]
+	if s.frame.depth >= in.maxDepth {
+		return fmt.Errorf("%s: maximum include depth %d reached", name, in.maxDepth)
+	}
+	from := in.Position()
+	fr := &frame{name: name, f: f, line: 1, parent: s.frame, from: &from, depth: s.frame.depth + 1}
+	pos := in.Offset()
+	s.end = s.frame.f.Offset()
+	in.segs = append(in.segs, segment{frame: fr, start: f.Offset(), end: -1, pos: pos})
+	in.cur++
+	return nil
+}
+
+// Position returns the position at the current offset.
+func (in *includeFile) Position() Position {
+	fr := in.segs[in.cur].frame
+	return Position{Name: fr.name, Line: fr.line, Offset: fr.f.Offset(), IncludedFrom: fr.from}
+}
+
+// Depth returns the number of includes that contain the current offset.
+func (in *includeFile) Depth() int {
+	return in.segs[in.cur].frame.depth
+}
+
+// Name returns the name of the input at the current offset.
+func (in *includeFile) Name() string {
+	return in.segs[in.cur].frame.name
+}
+
+// forward calls step on the input of the current segment until it does not return eof, moving to the next segment
+// at the end of each one. At the EOF of an included input, the outer input continues.
+func (in *includeFile) forward(step func(fr *frame, max int64) (eof bool)) (eof bool) {
+	for {
+		s := &in.segs[in.cur]
+		max := int64(-1)
+		if s.end >= 0 {
+			max = s.end - s.frame.f.Offset()
+		}
+		if max != 0 && !step(s.frame, max) {
+			return false
+		}
+		if in.cur+1 < len(in.segs) {
+			in.cur++
+			continue
+		}
+		if s.frame.parent == nil {
+			return true
+		}
+		pos := in.Offset()
+		s.end = s.frame.f.Offset()
+		parent := s.frame.parent
+		in.segs = append(in.segs, segment{frame: parent, start: parent.f.Offset(), end: -1, pos: pos})
+		in.cur++
+	}
+}
+
+// backward calls step on the input of the current segment until it does not return onStart, moving to the previous
+// segment at the start of each one.
+func (in *includeFile) backward(step func(fr *frame) (onStart bool)) (onStart bool) {
+	for {
+		s := &in.segs[in.cur]
+		if s.frame.f.Offset() > s.start && !step(s.frame) {
+			return false
+		}
+		if in.cur == 0 {
+			return true
+		}
+		in.cur--
+	}
+}
+
+// Next returns the rune at the current offset.
+func (in *includeFile) Next() (r rune, eof bool) {
+	eof = in.forward(func(fr *frame, max int64) bool {
+		var eof bool
+		if r, eof = fr.f.Next(); r == '\n' && !eof {
+			fr.line++
+		}
+		return eof
+	})
+	return r, eof
+}
+
+// Previous returns the rune before the current offset.
+func (in *includeFile) Previous() (r rune, onStart bool) {
+	onStart = in.backward(func(fr *frame) bool {
+		var onStart bool
+		if r, onStart = fr.f.Previous(); r == '\n' && !onStart {
+			fr.line--
+		}
+		return onStart
+	})
+	return r, onStart
+}
+
+// NextByte returns the byte at the current offset.
+func (in *includeFile) NextByte() (b byte, eof bool) {
+	eof = in.forward(func(fr *frame, max int64) bool {
+		var eof bool
+		if b, eof = fr.f.NextByte(); b == '\n' && !eof {
+			fr.line++
+		}
+		return eof
+	})
+	return b, eof
+}
+
+// PreviousByte returns the byte before the current offset.
+func (in *includeFile) PreviousByte() (b byte, onStart bool) {
+	onStart = in.backward(func(fr *frame) bool {
+		var onStart bool
+		if b, onStart = fr.f.PreviousByte(); b == '\n' && !onStart {
+			fr.line--
+		}
+		return onStart
+	})
+	return b, onStart
+}
+
+// ReadBytes returns the next n bytes.
+func (in *includeFile) ReadBytes(n int) (p []byte, eof bool) {
+	if n <= 0 {
+		return nil, false
+	}
+	eof = in.forward(func(fr *frame, max int64) bool {
+		m := n - len(p)
+		if max >= 0 {
+			m = int(min(int64(m), max))
+		}
+		q, eof := fr.f.ReadBytes(m)
+		fr.line += strings.Count(string(q), "\n")
+		p = append(p, q...)
+		return eof || len(p) < n
+	})
+	return p, eof
+}
+
+// IndexRune returns the offset of the first instance of r at or after the current offset, or -1 if r is not present.
+func (in *includeFile) IndexRune(r rune) int64 {
+	return indexFile(in, utf8.AppendRune(nil, r))
+}
+
+// IndexString returns the offset of the first instance of s at or after the current offset, or -1 if s is not present.
+func (in *includeFile) IndexString(s string) int64 {
+	return indexFile(in, []byte(s))
+}
+
+// LastIndexRune returns the offset of the last instance of r that ends at or before the current offset, or -1 if r is
+// not present.
+func (in *includeFile) LastIndexRune(r rune) int64 {
+	return lastIndexFile(in, utf8.AppendRune(nil, r))
+}
+
+// LastIndexString returns the offset of the last instance of s that ends at or before the current offset, or -1 if s
+// is not present.
+func (in *includeFile) LastIndexString(s string) int64 {
+	return lastIndexFile(in, []byte(s))
+}
+
+// SkipUntil put the offset at the start of the first instance of delim at or after the current offset.
+func (in *includeFile) SkipUntil(delim string) (found bool) {
+	return skipFile(in, []byte(delim))
+}
+
+// Consumed marks the bytes before offset as consumed. The inputs that end at or before offset are closed.
+func (in *includeFile) Consumed(offset int64) {
+	if offset > in.Offset() {
+		panic(errors.New("invalid offset"))
+	}
+	if !in.tx.deferConsumed(offset) {
+		in.consume(offset)
+	}
+}
+
+// consume removes the segments that end at or before offset, closing their inputs if they are not used anymore, and
+// marks the bytes before offset as consumed in the input of the segment that contains offset.
+func (in *includeFile) consume(offset int64) {
+	for in.cur > 0 && in.segs[1].pos <= offset {
+		s := in.segs[0]
+		in.segs = in.segs[1:]
+		in.cur--
+		if in.uses(s.frame) {
+			s.frame.f.Consumed(s.end)
+		} else if err := s.frame.f.Close(); err != nil {
+			panic(err)
+		}
+	}
+	if s := in.segs[0]; offset >= s.pos {
+		s.frame.f.Consumed(s.start + offset - s.pos)
+	}
+}
+
+// uses reports if fr is the input of a segment, or an outer input of the last segment.
+func (in *includeFile) uses(fr *frame) bool {
+	for _, s := range in.segs {
+		if s.frame == fr {
+			return true
+		}
+	}
+	for p := in.segs[len(in.segs)-1].frame; p != nil; p = p.parent {
+		if p == fr {
+			return true
+		}
+	}
+	return false
+}
+
+// frames returns the inputs in use.
+func (in *includeFile) frames() []*frame {
+	var frames []*frame
+	seen := make(map[*frame]bool)
+	add := func(fr *frame) {
+		for ; fr != nil && !seen[fr]; fr = fr.parent {
+			seen[fr] = true
+			frames = append(frames, fr)
+		}
+	}
+	for _, s := range in.segs {
+		add(s.frame)
+	}
+	return frames
+}
+
+// Begin begins a transaction at the current offset. Transactions can be nested.
+func (in *includeFile) Begin() Tx {
+	return in.tx.begin(in)
+}
+
+// setOffset put the offset at offset.
+func (in *includeFile) setOffset(offset int64) {
+	seekFile(in, offset)
+}
+
+// Fork returns a new IncludeFile with forks of the inputs in use, with an independent offset initially equal to the
+// current offset of in.
+func (in *includeFile) Fork() File {
+	forks := make(map[*frame]*frame)
+	var fork func(fr *frame) *frame
+	fork = func(fr *frame) *frame {
+		if fr == nil {
+			return nil
+		}
+		if f, ok := forks[fr]; ok {
+			return f
+		}
+		f := &frame{name: fr.name, f: fr.f.Fork(), line: fr.line, parent: fork(fr.parent), from: fr.from, depth: fr.depth}
+		forks[fr] = f
+		return f
+	}
+	segs := make([]segment, len(in.segs))
+	for i, s := range in.segs {
+		segs[i] = s
+		segs[i].frame = fork(s.frame)
+	}
+	return &includeFile{segs: segs, cur: in.cur, maxDepth: in.maxDepth}
+}
+
+// Offset returns the current offset.
+func (in *includeFile) Offset() int64 {
+	s := in.segs[in.cur]
+	return s.pos + s.frame.f.Offset() - s.start
+}
+
+// Close closes the inputs in use.
+func (in *includeFile) Close() error {
+	var errs []error
+	for _, fr := range in.frames() {
+		errs = append(errs, fr.f.Close())
+	}
+	return errors.Join(errs...)
+}
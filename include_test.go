@@ -0,0 +1,112 @@
+package rem
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestIncludeFile tests the expansion of includes, the positions and the walk back across the include boundaries.
+func TestIncludeFile(t *testing.T) {
+	in := NewIncludeFile("a", NewFileFromString("a1\n#b\na3"), 2)
+	defer in.Close()
+
+	in.ReadBytes(5)
+	if err := in.Push("b", NewFileFromString("b1\n#c\nb3")); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	in.ReadBytes(5)
+	if err := in.Push("c", NewFile([]byte("c1\nc2"))); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := in.Push("d", NewFile(nil)); err == nil {
+		t.Errorf("expected an error of the maximum depth")
+	}
+	in.ReadBytes(4)
+	if pos := in.Position().String(); pos != "c:2, included from b:2, included from a:2" {
+		t.Errorf("unexpected position %q", pos)
+	}
+	if depth := in.Depth(); depth != 2 {
+		t.Errorf("unexpected depth %d", depth)
+	}
+
+	if got := readString(in); got != "2\nb3\na3" {
+		t.Errorf("unexpected content %q", got)
+	}
+	want := "a1\n#bb1\n#cc1\nc2\nb3\na3"
+	if offset := in.Offset(); offset != int64(len(want)) {
+		t.Errorf("unexpected offset %d", offset)
+	}
+	if pos := in.Position(); pos.Name != "a" || pos.Line != 3 || pos.Offset != 8 || pos.IncludedFrom != nil {
+		t.Errorf("unexpected position %v", pos)
+	}
+	if err := in.Push("d", NewFile(nil)); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	runes := []rune(want)
+	for i := len(runes) - 1; i >= 0; i-- {
+		if r, onStart := in.Previous(); onStart || r != runes[i] {
+			t.Fatalf("expected %q at %d, got %q", runes[i], i, r)
+		}
+	}
+	if _, onStart := in.Previous(); !onStart {
+		t.Errorf("expected the start")
+	}
+	if pos := in.Position(); pos.Name != "a" || pos.Line != 1 {
+		t.Errorf("unexpected position %v", pos)
+	}
+	if err := in.Push("d", NewFile(nil)); err == nil {
+		t.Errorf("expected an error of a push before the last boundary")
+	}
+
+	if i := in.IndexString("#cc1"); i != 8 {
+		t.Errorf("unexpected index %d", i)
+	}
+	if p, eof := in.ReadBytes(len(want) + 1); !eof || string(p) != want {
+		t.Errorf("unexpected result %q, %v", p, eof)
+	}
+	if i := in.LastIndexRune('#'); i != 8 {
+		t.Errorf("unexpected index %d", i)
+	}
+}
+
+// TestIncludeFileConsumed tests if the included inputs are closed when they are consumed, and the transactions and
+// forks.
+func TestIncludeFileConsumed(t *testing.T) {
+	closed := 0
+	in := NewIncludeFile("a", closeCounter{NewFileFromString("ab"), &closed}, 1)
+	in.NextByte()
+	in.Push("b", closeCounter{NewFileFromReader(strings.NewReader("cd"), 4, 0, ""), &closed})
+
+	tx := in.Begin()
+	in.ReadBytes(3)
+	in.Consumed(3)
+	tx.Rollback()
+	if offset := in.Offset(); offset != 1 || closed != 0 {
+		t.Errorf("unexpected offset %d and %d inputs closed", offset, closed)
+	}
+
+	fork := in.Fork()
+	in.ReadBytes(4)
+	if name := Name(in); name != "a" {
+		t.Errorf("unexpected name %q", name)
+	}
+	in.Consumed(3)
+	if closed != 1 {
+		t.Errorf("expected 1 input closed, got %d", closed)
+	}
+	if err := in.Close(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if closed != 2 {
+		t.Errorf("expected 2 inputs closed, got %d", closed)
+	}
+
+	if got := readString(fork); got != "cdb" {
+		t.Errorf("unexpected content %q", got)
+	}
+	if pos := fork.(IncludeFile).Position().String(); pos != "a:1" {
+		t.Errorf("unexpected position %q", pos)
+	}
+	fork.Close()
+}
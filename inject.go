@@ -0,0 +1,275 @@
+package rem
+
+import (
+	"errors"
+	"unicode/utf8"
+)
+
+// InjectFile is a File that reads runes injected by the client before continuing with its input. The offsets count
+// the injected bytes, so after an injection they are different from the offsets of the input. Source maps them.
+type InjectFile interface {
+	File
+
+	// Unread injects runes at the current offset, so Next returns them first.
+	Unread(runes ...rune)
+
+	// Inject injects s at the current offset, so Next returns its runes first.
+	Inject(s string)
+
+	// Source returns the offset in the input that corresponds to the current offset, and whether the rune at the
+	// current offset is injected. In the last case, offset is the offset in the input where the rune was injected.
+	Source() (offset int64, synthetic bool)
+}
+
+// piece is a range of the input or of an injected text in an injectFile.
+type piece struct {
+	// f is the input, or a File that reads the injected text.
+	f File
+	// synthetic reports whether f reads injected text.
+	synthetic bool
+	// start is the offset of the start of the piece in f.
+	start int64
+	// end is the offset of the end of the piece in f, or -1 if it is the last piece.
+	end int64
+	// pos is the offset of the start of the piece in the injectFile.
+	pos int64
+}
+
+// injectFile is an InjectFile.
+type injectFile struct {
+	// pieces are the pieces read so far. The consumed pieces are removed. The last piece is a range of the input.
+	pieces []piece
+	// cur is the index of the current piece. The Files are at the offsets that correspond to the current offset in each
+	// piece.
+	cur int
+	// tx are the open transactions.
+	tx transactions
+}
+
+// NewInjectFile returns an InjectFile that reads f from its current offset. The offsets start at the current offset of
+// f.
+func NewInjectFile(f File) InjectFile {
+	offset := f.Offset()
+	return &injectFile{pieces: []piece{{f: f, start: offset, end: -1, pos: offset}}}
+}
+
+// Unread injects runes at the current offset.
+func (inj *injectFile) Unread(runes ...rune) {
+	inj.Inject(string(runes))
+}
+
+// Inject injects s at the current offset. If the current offset is inside a piece, the piece is split.
+func (inj *injectFile) Inject(s string) {
+	if s == "" {
+		return
+	}
+	p := inj.pieces[inj.cur]
+	offset := p.f.Offset()
+	pos := inj.Offset()
+	text := piece{f: newBytesFile([]byte(s)), synthetic: true, end: int64(len(s)), pos: pos}
+	rest := piece{f: p.f, synthetic: p.synthetic, start: offset, end: p.end, pos: pos + int64(len(s))}
+	inj.pieces[inj.cur].end = offset
+	tail := append([]piece{text, rest}, inj.pieces[inj.cur+1:]...)
+	for i := 2; i < len(tail); i++ {
+		tail[i].pos += int64(len(s))
+	}
+	inj.pieces = append(inj.pieces[:inj.cur+1], tail...)
+	inj.cur++
+}
+
+// Source returns the offset in the input that corresponds to the current offset, and whether the rune at the current
+// offset is injected.
+func (inj *injectFile) Source() (offset int64, synthetic bool) {
+	i := inj.cur
+	if p := inj.pieces[i]; p.end >= 0 && p.f.Offset() == p.end {
+		i++
+	}
+	for j := i; ; j++ {
+		if p := inj.pieces[j]; !p.synthetic {
+			if j == i {
+				return p.f.Offset(), false
+			}
+			return p.start, true
+		}
+	}
+}
+
+// forward calls step on the File of the current piece until it does not return eof, moving to the next piece at the
+// end of each one.
+func (inj *injectFile) forward(step func(f File, max int64) (eof bool)) (eof bool) {
+	for {
+		p := inj.pieces[inj.cur]
+		max := int64(-1)
+		if p.end >= 0 {
+			max = p.end - p.f.Offset()
+		}
+		if max != 0 && !step(p.f, max) {
+			return false
+		}
+		if inj.cur+1 == len(inj.pieces) {
+			return true
+		}
+		inj.cur++
+	}
+}
+
+// backward calls step on the File of the current piece until it does not return onStart, moving to the previous piece
+// at the start of each one.
+func (inj *injectFile) backward(step func(f File) (onStart bool)) (onStart bool) {
+	for {
+		p := inj.pieces[inj.cur]
+		if p.f.Offset() > p.start && !step(p.f) {
+			return false
+		}
+		if inj.cur == 0 {
+			return true
+		}
+		inj.cur--
+	}
+}
+
+// Next returns the rune at the current offset.
+func (inj *injectFile) Next() (r rune, eof bool) {
+	eof = inj.forward(func(f File, max int64) bool {
+		var eof bool
+		r, eof = f.Next()
+		return eof
+	})
+	return r, eof
+}
+
+// Previous returns the rune before the current offset.
+func (inj *injectFile) Previous() (r rune, onStart bool) {
+	onStart = inj.backward(func(f File) bool {
+		var onStart bool
+		r, onStart = f.Previous()
+		return onStart
+	})
+	return r, onStart
+}
+
+// NextByte returns the byte at the current offset.
+func (inj *injectFile) NextByte() (b byte, eof bool) {
+	eof = inj.forward(func(f File, max int64) bool {
+		var eof bool
+		b, eof = f.NextByte()
+		return eof
+	})
+	return b, eof
+}
+
+// PreviousByte returns the byte before the current offset.
+func (inj *injectFile) PreviousByte() (b byte, onStart bool) {
+	onStart = inj.backward(func(f File) bool {
+		var onStart bool
+		b, onStart = f.PreviousByte()
+		return onStart
+	})
+	return b, onStart
+}
+
+// ReadBytes returns the next n bytes.
+func (inj *injectFile) ReadBytes(n int) (p []byte, eof bool) {
+	if n <= 0 {
+		return nil, false
+	}
+	eof = inj.forward(func(f File, max int64) bool {
+		m := n - len(p)
+		if max >= 0 {
+			m = int(min(int64(m), max))
+		}
+		q, eof := f.ReadBytes(m)
+		p = append(p, q...)
+		return eof || len(p) < n
+	})
+	return p, eof
+}
+
+// IndexRune returns the offset of the first instance of r at or after the current offset, or -1 if r is not present.
+func (inj *injectFile) IndexRune(r rune) int64 {
+	return indexFile(inj, utf8.AppendRune(nil, r))
+}
+
+// IndexString returns the offset of the first instance of s at or after the current offset, or -1 if s is not present.
+func (inj *injectFile) IndexString(s string) int64 {
+	return indexFile(inj, []byte(s))
+}
+
+// LastIndexRune returns the offset of the last instance of r that ends at or before the current offset, or -1 if r is
+// not present.
+func (inj *injectFile) LastIndexRune(r rune) int64 {
+	return lastIndexFile(inj, utf8.AppendRune(nil, r))
+}
+
+// LastIndexString returns the offset of the last instance of s that ends at or before the current offset, or -1 if s
+// is not present.
+func (inj *injectFile) LastIndexString(s string) int64 {
+	return lastIndexFile(inj, []byte(s))
+}
+
+// SkipUntil put the offset at the start of the first instance of delim at or after the current offset.
+func (inj *injectFile) SkipUntil(delim string) (found bool) {
+	return skipFile(inj, []byte(delim))
+}
+
+// Consumed marks the bytes before offset as consumed.
+func (inj *injectFile) Consumed(offset int64) {
+	if offset > inj.Offset() {
+		panic(errors.New("invalid offset"))
+	}
+	if !inj.tx.deferConsumed(offset) {
+		inj.consume(offset)
+	}
+}
+
+// consume removes the pieces that end at or before offset, and marks the bytes of the input before offset as consumed.
+func (inj *injectFile) consume(offset int64) {
+	for inj.cur > 0 && inj.pieces[1].pos <= offset {
+		inj.pieces = inj.pieces[1:]
+		inj.cur--
+	}
+	if p := inj.pieces[0]; offset >= p.pos {
+		for i := 0; ; i++ {
+			if p := inj.pieces[i]; !p.synthetic {
+				p.f.Consumed(p.start + max(0, offset-p.pos))
+				return
+			}
+		}
+	}
+}
+
+// Begin begins a transaction at the current offset. Transactions can be nested.
+func (inj *injectFile) Begin() Tx {
+	return inj.tx.begin(inj)
+}
+
+// setOffset put the offset at offset.
+func (inj *injectFile) setOffset(offset int64) {
+	seekFile(inj, offset)
+}
+
+// Fork returns a new InjectFile with a fork of the input and the same injected texts, with an independent offset
+// initially equal to the current offset of inj.
+func (inj *injectFile) Fork() File {
+	pieces := make([]piece, len(inj.pieces))
+	forks := make(map[File]File)
+	for i, p := range inj.pieces {
+		if _, ok := forks[p.f]; !ok {
+			forks[p.f] = p.f.Fork()
+		}
+		pieces[i] = p
+		pieces[i].f = forks[p.f]
+	}
+	return &injectFile{pieces: pieces, cur: inj.cur}
+}
+
+// Offset returns the current offset.
+func (inj *injectFile) Offset() int64 {
+	p := inj.pieces[inj.cur]
+	return p.pos + p.f.Offset() - p.start
+}
+
+// Close closes the input.
+func (inj *injectFile) Close() error {
+	return inj.pieces[len(inj.pieces)-1].f.Close()
+}
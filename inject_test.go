@@ -0,0 +1,99 @@
+package rem
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestInjectFile tests the reading of injected runes, the offsets and Previous across the boundaries.
+func TestInjectFile(t *testing.T) {
+	inj := NewInjectFile(NewFileFromString("ab}"))
+	defer inj.Close()
+
+	inj.ReadBytes(2)
+	inj.Unread(';')
+	if offset, synthetic := inj.Source(); offset != 2 || !synthetic {
+		t.Errorf("unexpected source %d, %v", offset, synthetic)
+	}
+	if r, _ := inj.Next(); r != ';' {
+		t.Errorf("expected ';', got %q", r)
+	}
+	if offset, synthetic := inj.Source(); offset != 2 || synthetic {
+		t.Errorf("unexpected source %d, %v", offset, synthetic)
+	}
+	if offset := inj.Offset(); offset != 3 {
+		t.Errorf("unexpected offset %d", offset)
+	}
+
+	inj.Previous()
+	inj.Previous()
+	inj.Inject("xçz")
+	if got := readString(inj); got != "xçzb;}" {
+		t.Errorf("unexpected content %q", got)
+	}
+	for _, want := range "}" {
+		if r, _ := inj.Previous(); r != want {
+			t.Errorf("expected %q, got %q", want, r)
+		}
+	}
+	inj.Inject("(")
+	if got := readString(inj); got != "(}" {
+		t.Errorf("unexpected content %q", got)
+	}
+	want := "axçzb;(}"
+	for i := len([]rune(want)) - 1; i >= 0; i-- {
+		if r, onStart := inj.Previous(); onStart || r != []rune(want)[i] {
+			t.Fatalf("expected %q at %d, got %q", []rune(want)[i], i, r)
+		}
+	}
+	if _, onStart := inj.Previous(); !onStart {
+		t.Errorf("expected the start")
+	}
+
+	inj.ReadBytes(2)
+	if offset, synthetic := inj.Source(); offset != 1 || !synthetic {
+		t.Errorf("unexpected source %d, %v", offset, synthetic)
+	}
+	if i := inj.IndexString("b;("); i != 5 {
+		t.Errorf("unexpected index %d", i)
+	}
+	if !inj.SkipUntil("}") || inj.Offset() != 8 {
+		t.Errorf("unexpected offset %d", inj.Offset())
+	}
+	if i := inj.LastIndexRune('ç'); i != 2 {
+		t.Errorf("unexpected index %d", i)
+	}
+}
+
+// TestInjectFileConsumed tests the consumption, the transactions and the forks.
+func TestInjectFileConsumed(t *testing.T) {
+	inj := NewInjectFile(NewFileFromReader(strings.NewReader("abcdef"), 2, 0, ""))
+	defer inj.Close()
+	inj.ReadBytes(2)
+	inj.Inject("xy")
+
+	tx := inj.Begin()
+	inj.ReadBytes(3)
+	tx.Rollback()
+	if offset := inj.Offset(); offset != 2 {
+		t.Errorf("unexpected offset %d", offset)
+	}
+
+	inj.NextByte()
+	fork := inj.Fork()
+	defer fork.Close()
+	inj.Consumed(3)
+	if got := readString(inj); got != "ycdef" {
+		t.Errorf("unexpected content %q", got)
+	}
+	inj.Consumed(6)
+	if len(inj.(*injectFile).pieces) != 1 {
+		t.Errorf("expected that the injected text was removed")
+	}
+	if got := readString(fork); got != "ycdef" {
+		t.Errorf("unexpected content %q", got)
+	}
+	if r, _ := fork.Previous(); r != 'f' {
+		t.Errorf("expected 'f', got %q", r)
+	}
+}
@@ -0,0 +1,182 @@
+// This package provides the primitives needed by lexers that read from a rem.File.
+package lex
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/joaobnv/rem"
+)
+
+// EOF is the rune returned by Next when the input is at EOF.
+const EOF rune = -1
+
+// TokenType identifies the type of a token. The values less than zero are reserved for the types defined in this package.
+type TokenType int
+
+const (
+	// Error is the type of the tokens emitted by Errorf. The Value of the token is the error message.
+	Error TokenType = -2
+	// EOFToken is the type of the token returned by NextToken when the lexing ends.
+	EOFToken TokenType = -1
+)
+
+// Token is a span of the input emitted by the lexer.
+type Token struct {
+	// Type is the type of the token.
+	Type TokenType
+	// Start is the offset of the first byte of the token.
+	Start int64
+	// End is the offset immediately after the last byte of the token.
+	End int64
+	// Value is the text of the token.
+	Value string
+}
+
+// String returns a representation of t useful for debugging.
+func (t Token) String() string {
+	return fmt.Sprintf("%d[%d:%d]%q", t.Type, t.Start, t.End, t.Value)
+}
+
+// StateFn represents a state of the lexer as a function that returns the next state. A nil StateFn ends the lexing.
+type StateFn func(*Lexer) StateFn
+
+// Lexer holds the state of a lexer.
+type Lexer struct {
+	// f is the input.
+	f rem.File
+	// state is the next state function to run.
+	state StateFn
+	// start is the offset of the start of the current token.
+	start int64
+	// text is the text read since start.
+	text []byte
+	// atEOF reports whether the last call to Next returned EOF.
+	atEOF bool
+	// tokens are the emitted tokens that were not returned by NextToken yet.
+	tokens []Token
+}
+
+// New creates a new Lexer that reads from f starting at its current offset. start is the initial state.
+func New(f rem.File, start StateFn) *Lexer {
+	return &Lexer{f: f, state: start, start: f.Offset()}
+}
+
+// NextToken runs the state functions until a token is emitted and returns it. When the lexing ends, NextToken
+// returns a token of type EOFToken.
+func (l *Lexer) NextToken() Token {
+	for len(l.tokens) == 0 {
+		if l.state == nil {
+			return Token{Type: EOFToken, Start: l.start, End: l.start}
+		}
+		l.state = l.state(l)
+	}
+	t := l.tokens[0]
+	l.tokens = l.tokens[1:]
+	return t
+}
+
+// Next returns the next rune of the input, or EOF if the input is at EOF.
+func (l *Lexer) Next() rune {
+	r, eof := l.f.Next()
+	if eof {
+		l.atEOF = true
+		return EOF
+	}
+	l.atEOF = false
+	l.text = utf8.AppendRune(l.text, r)
+	return r
+}
+
+// Peek returns the next rune of the input but dont consumes it.
+func (l *Lexer) Peek() rune {
+	r := l.Next()
+	l.Backup()
+	return r
+}
+
+// Backup steps back one rune. It can be called multiple times, but not beyond the start of the current token. If the
+// last call to Next returned EOF, the first call to Backup does nothing.
+func (l *Lexer) Backup() {
+	if l.atEOF {
+		l.atEOF = false
+		return
+	}
+	if len(l.text) == 0 {
+		panic(errors.New("backup beyond the start of the token"))
+	}
+	l.f.Previous()
+	_, size := utf8.DecodeLastRune(l.text)
+	l.text = l.text[:len(l.text)-size]
+}
+
+// Accept consumes the next rune if it is in valid.
+func (l *Lexer) Accept(valid string) bool {
+	return l.AcceptFunc(func(r rune) bool { return strings.ContainsRune(valid, r) })
+}
+
+// AcceptRun consumes a run of runes from valid. It returns the number of runes consumed.
+func (l *Lexer) AcceptRun(valid string) int {
+	return l.AcceptRunFunc(func(r rune) bool { return strings.ContainsRune(valid, r) })
+}
+
+// AcceptFunc consumes the next rune if fn returns true for it.
+func (l *Lexer) AcceptFunc(fn func(rune) bool) bool {
+	r := l.Next()
+	if r != EOF && fn(r) {
+		return true
+	}
+	l.Backup()
+	return false
+}
+
+// AcceptRunFunc consumes a run of runes for which fn returns true. It returns the number of runes consumed.
+func (l *Lexer) AcceptRunFunc(fn func(rune) bool) (n int) {
+	for l.AcceptFunc(fn) {
+		n++
+	}
+	return
+}
+
+// Start returns the offset of the start of the current token.
+func (l *Lexer) Start() int64 {
+	return l.start
+}
+
+// Offset returns the current offset.
+func (l *Lexer) Offset() int64 {
+	return l.f.Offset()
+}
+
+// Text returns the text of the current token.
+func (l *Lexer) Text() string {
+	return string(l.text)
+}
+
+// Emit emits a token of type t with the text read since the start of the current token. The input before the
+// current offset is marked as consumed.
+func (l *Lexer) Emit(t TokenType) {
+	l.emit(Token{Type: t, Start: l.start, End: l.f.Offset(), Value: string(l.text)})
+}
+
+// Ignore discards the text read since the start of the current token. The input before the current offset is marked
+// as consumed.
+func (l *Lexer) Ignore() {
+	l.start = l.f.Offset()
+	l.text = l.text[:0]
+	l.f.Consumed(l.start)
+}
+
+// Errorf emits a token of type Error whose Value is the formatted message and returns nil, ending the lexing.
+func (l *Lexer) Errorf(format string, args ...any) StateFn {
+	l.emit(Token{Type: Error, Start: l.start, End: l.f.Offset(), Value: fmt.Sprintf(format, args...)})
+	return nil
+}
+
+// emit appends t to the emitted tokens and starts a new token at the current offset.
+func (l *Lexer) emit(t Token) {
+	l.tokens = append(l.tokens, t)
+	l.Ignore()
+}
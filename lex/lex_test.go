@@ -0,0 +1,127 @@
+package lex
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/joaobnv/rem"
+)
+
+const (
+	number TokenType = iota
+	identifier
+	operator
+)
+
+// lexAny is the start state of the lexer used in the tests.
+func lexAny(l *Lexer) StateFn {
+	switch r := l.Peek(); {
+	case r == EOF:
+		return nil
+	case unicode.IsSpace(r):
+		l.AcceptRunFunc(unicode.IsSpace)
+		l.Ignore()
+		return lexAny
+	case unicode.IsDigit(r):
+		return lexNumber
+	case unicode.IsLetter(r):
+		l.AcceptRunFunc(unicode.IsLetter)
+		l.Emit(identifier)
+		return lexAny
+	case l.Accept("+-*/"):
+		l.Emit(operator)
+		return lexAny
+	default:
+		return l.Errorf("unexpected rune %q", r)
+	}
+}
+
+// lexNumber lexes a number with an optional fraction.
+func lexNumber(l *Lexer) StateFn {
+	l.AcceptRun("0123456789")
+	if l.Accept(".") {
+		if l.AcceptRun("0123456789") == 0 {
+			l.Backup()
+		}
+	}
+	l.Emit(number)
+	return lexAny
+}
+
+// consumedRecorder is a rem.File that records the calls to Consumed.
+type consumedRecorder struct {
+	rem.File
+	offsets []int64
+}
+
+// Consumed records offset and calls the Consumed of the File.
+func (cr *consumedRecorder) Consumed(offset int64) {
+	cr.offsets = append(cr.offsets, offset)
+	cr.File.Consumed(offset)
+}
+
+// TestLexer tests the Lexer.
+func TestLexer(t *testing.T) {
+	input := "año 12.5+ 3. x"
+	expected := []Token{
+		{identifier, 0, 4, "año"},
+		{number, 5, 9, "12.5"},
+		{operator, 9, 10, "+"},
+		{number, 11, 12, "3"},
+		{Error, 12, 12, "unexpected rune '.'"},
+		{EOFToken, 12, 12, ""},
+	}
+
+	files := []rem.File{
+		rem.NewFile([]byte(input)),
+		rem.NewFileFromReader(bufio.NewReader(strings.NewReader(input)), 4, 1<<10, t.TempDir()),
+	}
+
+	for _, f := range files {
+		cr := &consumedRecorder{File: f}
+		l := New(cr, lexAny)
+		for _, et := range expected {
+			if tok := l.NextToken(); tok != et {
+				t.Errorf("%T: expected %v, got %v", f, et, tok)
+			}
+		}
+		if len(cr.offsets) == 0 || cr.offsets[len(cr.offsets)-1] != 12 {
+			t.Errorf("%T: expected the last consumed offset = 12, got %v", f, cr.offsets)
+		}
+		f.Close()
+	}
+}
+
+// TestBackupAfterEOF tests if Backup after a Next that returned EOF keeps the offset.
+func TestBackupAfterEOF(t *testing.T) {
+	l := New(rem.NewFile([]byte("ab")), nil)
+	l.Next()
+	l.Next()
+	if r := l.Next(); r != EOF {
+		t.Errorf("expected EOF, got %q", r)
+	}
+	l.Backup()
+	l.Backup()
+	if l.Offset() != 1 || l.Text() != "a" {
+		t.Errorf("expected offset = 1 and text %q, got %d and %q", "a", l.Offset(), l.Text())
+	}
+	if tok := l.NextToken(); tok.Type != EOFToken {
+		t.Errorf("expected EOF token, got %v", tok)
+	}
+}
+
+// TestPanicBackup tests if Backup panics if it is called beyond the start of the token.
+func TestPanicBackup(t *testing.T) {
+	defer func() {
+		if err := recover(); err == nil {
+			t.Errorf("panic expected")
+		}
+	}()
+
+	l := New(rem.NewFile([]byte("ab")), nil)
+	l.Next()
+	l.Ignore()
+	l.Backup()
+}
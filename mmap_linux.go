@@ -0,0 +1,112 @@
+//go:build linux
+
+package rem
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"syscall"
+)
+
+// mmapFile is a File that reads a memory-mapped file. Forks share the mapping.
+type mmapFile struct {
+	*bytesFile
+	// m is the mapping.
+	m *mapping
+}
+
+// mapping is a memory-mapped file shared by mmapFiles.
+type mapping struct {
+	mu sync.Mutex
+	// data is the mapped region.
+	data []byte
+	// cursors are the consumed offsets of the mmapFiles that use the mapping and are not closed.
+	cursors map[*mmapFile]int64
+	// released is the offset before which the pages were given back to the kernel.
+	released int64
+}
+
+// mmap maps the first size bytes of file and returns a File that reads them. file can be closed after mmap returns.
+func mmap(file *os.File, size int64) (File, error) {
+	if size == 0 {
+		return newBytesFile(nil), nil
+	}
+	data, err := syscall.Mmap(int(file.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, os.NewSyscallError("mmap", err)
+	}
+	mf := &mmapFile{bytesFile: newBytesFile(data), m: &mapping{data: data, cursors: make(map[*mmapFile]int64)}}
+	mf.m.cursors[mf] = 0
+	return mf, nil
+}
+
+// Consumed marks the bytes before offset as consumed. The pages consumed by all the forks are given back to the kernel.
+// If they are accessed again they are read from the file.
+func (mf *mmapFile) Consumed(offset int64) {
+	if offset > mf.Offset() {
+		panic(errors.New("invalid offset"))
+	}
+	if !mf.tx.deferConsumed(offset) {
+		mf.consume(offset)
+	}
+}
+
+// consume marks the bytes before offset as consumed.
+func (mf *mmapFile) consume(offset int64) {
+	mf.m.mu.Lock()
+	defer mf.m.mu.Unlock()
+	if consumed, ok := mf.m.cursors[mf]; ok {
+		mf.m.cursors[mf] = max(consumed, offset)
+		mf.m.release()
+	}
+}
+
+// Begin begins a transaction at the current offset. Transactions can be nested.
+func (mf *mmapFile) Begin() Tx {
+	return mf.tx.begin(mf)
+}
+
+// Fork returns a new mmapFile that shares the mapping of mf, with an independent offset initially equal to the current
+// offset of mf.
+func (mf *mmapFile) Fork() File {
+	mf.m.mu.Lock()
+	defer mf.m.mu.Unlock()
+	fork := &mmapFile{bytesFile: &bytesFile{b: mf.b, offset: mf.offset}, m: mf.m}
+	mf.m.cursors[fork] = mf.m.cursors[mf]
+	return fork
+}
+
+// Close unmaps the file when the last of the forks that share the mapping is closed. After Close, the reads of mf
+// panic instead of accessing the mapping.
+func (mf *mmapFile) Close() error {
+	mf.m.mu.Lock()
+	defer mf.m.mu.Unlock()
+	if _, ok := mf.m.cursors[mf]; !ok {
+		return nil
+	}
+	delete(mf.m.cursors, mf)
+	mf.b = nil
+	if len(mf.m.cursors) > 0 {
+		mf.m.release()
+		return nil
+	}
+	data := mf.m.data
+	mf.m.data = nil
+	return os.NewSyscallError("munmap", syscall.Munmap(data))
+}
+
+// release gives back to the kernel the pages consumed by all the cursors.
+func (m *mapping) release() {
+	consumed := int64(len(m.data))
+	for _, c := range m.cursors {
+		consumed = min(consumed, c)
+	}
+	pageSize := int64(os.Getpagesize())
+	end := consumed / pageSize * pageSize
+	if end > m.released {
+		// The advice is only a hint, so the error is ignored.
+		syscall.Madvise(m.data[m.released:end], syscall.MADV_DONTNEED)
+		m.released = end
+	}
+}
@@ -0,0 +1,88 @@
+//go:build linux
+
+package rem
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMmap tests the reading, the release of the consumed pages and the unmapping of a memory-mapped file.
+func TestMmap(t *testing.T) {
+	pageSize := os.Getpagesize()
+	data := strings.Repeat("abcdefgç", pageSize/2)
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := NewMmapFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	mf := f.(*mmapFile)
+	if got := readString(f); got != data {
+		t.Errorf("unexpected content")
+	}
+	fork := f.Fork()
+	if i := fork.LastIndexString("ç"); i != int64(len(data)-2) {
+		t.Errorf("unexpected index %d", i)
+	}
+
+	tx := f.Begin()
+	f.Consumed(int64(pageSize + 1))
+	if mf.m.released != 0 {
+		t.Errorf("expected that the pages were not released inside a transaction")
+	}
+	tx.Commit()
+	if mf.m.released != 0 {
+		t.Errorf("expected that the pages were not released before the fork consumes them")
+	}
+	fork.Consumed(int64(3 * pageSize))
+	if mf.m.released != int64(pageSize) {
+		t.Errorf("expected %d released bytes, got %d", pageSize, mf.m.released)
+	}
+	if r, _ := f.Previous(); r != 'ç' {
+		t.Errorf("expected 'ç', got %q", r)
+	}
+
+	if err := f.Close(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if mf.m.data == nil {
+		t.Errorf("expected that the mapping is used by the fork")
+	}
+	if err := fork.Close(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if mf.m.data != nil {
+		t.Errorf("expected that the file was unmapped")
+	}
+	if err := fork.Close(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("panic expected")
+		}
+	}()
+	fork.Next()
+}
+
+// TestMmapEmpty tests a memory-mapped empty file.
+func TestMmapEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := NewMmapFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	defer f.Close()
+	if _, eof := f.Next(); !eof {
+		t.Errorf("expected EOF")
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package rem
+
+import (
+	"errors"
+	"os"
+)
+
+// mmap returns errors.ErrUnsupported, because the memory-mapped backend is only available on Linux.
+func mmap(file *os.File, size int64) (File, error) {
+	return nil, errors.ErrUnsupported
+}
@@ -0,0 +1,127 @@
+package rem
+
+import "strconv"
+
+// LineEnding is a style of line endings.
+type LineEnding int
+
+const (
+	// NoLineEnding indicates that no line ending was read.
+	NoLineEnding LineEnding = iota
+	// LF is the style "\n".
+	LF
+	// CRLF is the style "\r\n".
+	CRLF
+	// CR is the style "\r".
+	CR
+	// MixedLineEndings indicates that more than one style was read.
+	MixedLineEndings
+)
+
+// String returns the name of the style.
+func (le LineEnding) String() string {
+	switch le {
+	case NoLineEnding:
+		return "none"
+	case LF:
+		return "LF"
+	case CRLF:
+		return "CRLF"
+	case CR:
+		return "CR"
+	case MixedLineEndings:
+		return "mixed"
+	}
+	return "LineEnding(" + strconv.Itoa(int(le)) + ")"
+}
+
+// NormalizedFile is a File that reads the line endings "\r\n" and "\r" of another File, the source, as "\n". The
+// offsets count the bytes after the normalization, SourceOffset maps them to the offsets of the source.
+type NormalizedFile interface {
+	File
+
+	// SourceOffset returns the offset in the source that corresponds to offset. offset must be an offset already read.
+	SourceOffset(offset int64) int64
+
+	// LineEnding returns the style of the line endings read so far.
+	LineEnding() LineEnding
+}
+
+// normalizedFile is a NormalizedFile.
+type normalizedFile struct {
+	*view
+	// c is the codec of the view.
+	c *newlineCodec
+}
+
+// NewNormalizedFile returns a NormalizedFile that reads f from its current offset. The offsets start at the current
+// offset of f. Close closes f.
+func NewNormalizedFile(f File) NormalizedFile {
+	c := &newlineCodec{}
+	return &normalizedFile{view: newView(f, c, false), c: c}
+}
+
+// LineEnding returns the style of the line endings read so far.
+func (nf *normalizedFile) LineEnding() LineEnding {
+	return nf.c.style
+}
+
+// Fork returns a new NormalizedFile with an independent offset initially equal to the current offset of nf.
+func (nf *normalizedFile) Fork() File {
+	v := nf.view.fork()
+	return &normalizedFile{view: v, c: v.c.(*newlineCodec)}
+}
+
+// newlineCodec is the codec of a NormalizedFile.
+type newlineCodec struct {
+	// style is the style of the line endings read.
+	style LineEnding
+}
+
+// next decodes the unit at the current offset of src, joining "\r\n".
+func (nc *newlineCodec) next(src File, fresh bool) (r rune, eof bool) {
+	if r, eof = src.Next(); eof || (r != '\r' && r != '\n') {
+		return r, eof
+	}
+	style := LF
+	if r == '\r' {
+		style = CR
+		if r, eof := src.Next(); !eof && r == '\n' {
+			style = CRLF
+		} else if !eof {
+			src.Previous()
+		}
+	}
+	if fresh {
+		nc.see(style)
+	}
+	return '\n', false
+}
+
+// see records a line ending of style.
+func (nc *newlineCodec) see(style LineEnding) {
+	if nc.style == NoLineEnding {
+		nc.style = style
+	} else if nc.style != style {
+		nc.style = MixedLineEndings
+	}
+}
+
+// previous decodes the unit before the current offset of src, joining "\r\n".
+func (nc *newlineCodec) previous(src File) (r rune, onStart bool) {
+	if r, onStart = src.Previous(); onStart || (r != '\r' && r != '\n') {
+		return r, onStart
+	}
+	if r == '\n' {
+		if r, onStart := src.Previous(); !onStart && r != '\r' {
+			src.Next()
+		}
+	}
+	return '\n', false
+}
+
+// clone returns a copy of nc.
+func (nc *newlineCodec) clone() codec {
+	c := *nc
+	return &c
+}
@@ -0,0 +1,113 @@
+package rem
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestNormalizedFile tests the normalization in both directions and the mapping of the offsets.
+func TestNormalizedFile(t *testing.T) {
+	nf := NewNormalizedFile(NewFileFromString("a\r\nç\rb\n\r\n"))
+	defer nf.Close()
+
+	if got := readString(nf); got != "a\nç\nb\n\n" {
+		t.Errorf("unexpected content %q", got)
+	}
+	if offset := nf.Offset(); offset != 8 || nf.SourceOffset(offset) != 10 {
+		t.Errorf("unexpected offset %d", offset)
+	}
+	if le := nf.LineEnding(); le != MixedLineEndings {
+		t.Errorf("unexpected line ending %v", le)
+	}
+	for i, want := range []int64{0, 1, 3, 4, 5, 6, 7, 8, 10} {
+		if got := nf.SourceOffset(int64(i)); got != want {
+			t.Errorf("expected that %d maps to %d, got %d", i, want, got)
+		}
+	}
+
+	want := []rune("a\nç\nb\n\n")
+	for i := len(want) - 1; i >= 0; i-- {
+		if r, onStart := nf.Previous(); onStart || r != want[i] {
+			t.Fatalf("expected %q at %d, got %q", want[i], i, r)
+		}
+	}
+	if _, onStart := nf.Previous(); !onStart {
+		t.Errorf("expected the start")
+	}
+
+	if p, _ := nf.ReadBytes(4); string(p) != "a\n\xc3\xa7" {
+		t.Errorf("unexpected bytes %q", p)
+	}
+	nf.PreviousByte()
+	if b, _ := nf.PreviousByte(); b != 0xc3 {
+		t.Errorf("expected 0xc3, got %#x", b)
+	}
+	if offset := nf.Offset(); offset != 2 || nf.SourceOffset(offset) != 3 {
+		t.Errorf("unexpected offset %d", offset)
+	}
+	if i := nf.IndexString("\nb\n"); i != 4 {
+		t.Errorf("unexpected index %d", i)
+	}
+	if !nf.SkipUntil("b") || nf.Offset() != 5 {
+		t.Errorf("unexpected offset %d", nf.Offset())
+	}
+	if i := nf.LastIndexString("\nç"); i != 1 {
+		t.Errorf("unexpected index %d", i)
+	}
+}
+
+// TestNormalizedFileStyles tests the detection of the line endings.
+func TestNormalizedFileStyles(t *testing.T) {
+	tests := []struct {
+		data string
+		le   LineEnding
+	}{
+		{"a", NoLineEnding},
+		{"a\nb\n", LF},
+		{"a\r\nb\r\n", CRLF},
+		{"a\rb\r", CR},
+		{"a\r\nb\n", MixedLineEndings},
+	}
+	for _, test := range tests {
+		nf := NewNormalizedFile(NewFileFromString(test.data))
+		readString(nf)
+		nf.Previous()
+		nf.Previous()
+		readString(nf)
+		if le := nf.LineEnding(); le != test.le {
+			t.Errorf("%q: expected %v, got %v", test.data, test.le, le)
+		}
+	}
+	if s := LineEnding(9).String(); s != "LineEnding(9)" {
+		t.Errorf("unexpected string %q", s)
+	}
+}
+
+// TestNormalizedFileConsumed tests the consumption, the transactions and the forks on a stream.
+func TestNormalizedFileConsumed(t *testing.T) {
+	nf := NewNormalizedFile(NewFileFromReader(strings.NewReader("ab\r\ncd\r\nef"), 4, 0, ""))
+	defer nf.Close()
+	nf.ReadBytes(4)
+
+	tx := nf.Begin()
+	nf.ReadBytes(3)
+	nf.Consumed(6)
+	tx.Rollback()
+	if offset := nf.Offset(); offset != 4 {
+		t.Errorf("unexpected offset %d", offset)
+	}
+
+	fork := nf.Fork()
+	defer fork.Close()
+	nf.Consumed(4)
+	if got := readString(nf); got != "d\nef" {
+		t.Errorf("unexpected content %q", got)
+	}
+	nf.Consumed(8)
+	if got := readString(fork); got != "d\nef" {
+		t.Errorf("unexpected content %q", got)
+	}
+	if le := fork.(NormalizedFile).LineEnding(); le != CRLF {
+		t.Errorf("unexpected line ending %v", le)
+	}
+}
@@ -0,0 +1,77 @@
+package rem
+
+import (
+	"io"
+	"os"
+)
+
+const (
+	// smallFileSize is the maximum size of the files that Open reads fully into memory.
+	smallFileSize = 1 << 16
+	// mmapFileSize is the minimum size of the files that Open maps into memory.
+	mmapFileSize = 1 << 24
+	// openBlockSize is the size of the blocks cached by the Files returned by Open.
+	openBlockSize = 1 << 14
+	// openMaxBlocks is the maximum number of blocks cached by the Files returned by Open.
+	openMaxBlocks = 16
+	// openMemLimit is the memLimit of the Files returned by Open for files that are not regular.
+	openMemLimit = 1 << 20
+	// openDiskLimit is the diskLimit of the Files returned by Open for files that are not regular.
+	openDiskLimit = 1 << 30
+)
+
+// Open opens the file named path and returns a File that reads it. The backend depends on the file: small files are
+// read fully into memory, large files are mapped into memory on Linux, other regular files are read in cached blocks,
+// and files that are not regular, like pipes, use the io.Reader backend with the default directory for temporary
+// files. The file is closed when the File and all its forks are closed.
+func Open(path string, opts ...Option) (File, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	o := newOptions(opts)
+
+	if !info.Mode().IsRegular() {
+		return newClosingFile(NewFileFromReader(struct{ io.Reader }{file}, openMemLimit, openDiskLimit, "", opts...), file), nil
+	}
+	if info.Size() <= smallFileSize {
+		data, err := io.ReadAll(file)
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+		return withContext(o.ctx, newBytesFile(data)), nil
+	}
+	if info.Size() >= mmapFileSize {
+		if f, err := mmap(file, info.Size()); err == nil {
+			file.Close()
+			return withContext(o.ctx, f), nil
+		}
+	}
+	return newClosingFile(withContext(o.ctx, newReaderAt(newBlockCache(file, openBlockSize, openMaxBlocks))), file), nil
+}
+
+// NewMmapFile maps the file named path into memory and returns a File that reads it. The pages consumed by the File
+// and all its forks are given back to the kernel, and the file is unmapped when the File and all its forks are closed.
+// It returns an error that wraps errors.ErrUnsupported if the system is not Linux.
+func NewMmapFile(path string, opts ...Option) (File, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	f, err := mmap(file, info.Size())
+	if err != nil {
+		return nil, err
+	}
+	return withContext(newOptions(opts).ctx, f), nil
+}
@@ -0,0 +1,112 @@
+package rem
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestOpen tests the backends chosen by Open, and if Close closes the file.
+func TestOpen(t *testing.T) {
+	dir := t.TempDir()
+	small := filepath.Join(dir, "small")
+	large := filepath.Join(dir, "large")
+	data := strings.Repeat("0123456789abcdeç", smallFileSize/16)
+	if err := os.WriteFile(small, []byte("abç"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(large, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := Open(small)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if _, ok := f.(*bytesFile); !ok {
+		t.Errorf("expected a *bytesFile, got %T", f)
+	}
+	if got := readString(f); got != "abç" {
+		t.Errorf("unexpected content %q", got)
+	}
+	f.Close()
+
+	f, err = Open(large)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	cf := f.(*closingFile)
+	if _, ok := cf.File.(*readerAt).ra.(*blockCache); !ok {
+		t.Errorf("expected a block cache")
+	}
+	if got := readString(f); got != data {
+		t.Errorf("unexpected content")
+	}
+	if i := f.LastIndexString("01"); i != int64(len(data)-17) {
+		t.Errorf("unexpected index %d", i)
+	}
+	if err := f.Close(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, err := cf.c.list[0].(*os.File).Stat(); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("expected that the file was closed, got %v", err)
+	}
+
+	if _, err := Open(filepath.Join(dir, "missing")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected %v, got %v", os.ErrNotExist, err)
+	}
+}
+
+// TestOpenForkAfterClose tests if the forks keep reading the file after the original File is closed.
+func TestOpenForkAfterClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "large")
+	data := strings.Repeat("0123456789abcdeç", smallFileSize/16)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	fork := f.Fork()
+	forkOfFork := fork.Fork()
+	if err := f.Close(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if got := readString(fork); got != data {
+		t.Errorf("unexpected content")
+	}
+	fork.Close()
+	fork.Close()
+	if got := readString(forkOfFork); got != data {
+		t.Errorf("unexpected content")
+	}
+	if err := forkOfFork.Close(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, err := f.(*closingFile).c.list[0].(*os.File).Stat(); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("expected that the file was closed, got %v", err)
+	}
+}
+
+// TestBlockCache tests the reads across blocks and the eviction of the blocks.
+func TestBlockCache(t *testing.T) {
+	bc := newBlockCache(strings.NewReader("0123456789"), 4, 2)
+	p := make([]byte, 6)
+	if n, err := bc.ReadAt(p, 2); n != 6 || err != nil || string(p) != "234567" {
+		t.Errorf("unexpected result %d, %v, %q", n, err, p[:n])
+	}
+	if n, err := bc.ReadAt(p, 7); n != 3 || err != io.EOF || string(p[:n]) != "789" {
+		t.Errorf("unexpected result %d, %v, %q", n, err, p[:n])
+	}
+	if len(bc.blocks) != 2 || bc.blocks[0] != nil {
+		t.Errorf("expected that the block 0 was evicted, got %v", bc.recent)
+	}
+	if n, err := bc.ReadAt(p, 12); n != 0 || err != io.EOF {
+		t.Errorf("unexpected result %d, %v", n, err)
+	}
+}
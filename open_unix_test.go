@@ -0,0 +1,42 @@
+//go:build unix
+
+package rem
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// TestOpenPipe tests if Open reads a named pipe with the io.Reader backend.
+func TestOpenPipe(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pipe")
+	if err := syscall.Mkfifo(path, 0o600); err != nil {
+		t.Skipf("mkfifo: %v", err)
+	}
+	go func() {
+		w, err := os.OpenFile(path, os.O_WRONLY, 0)
+		if err != nil {
+			return
+		}
+		io.WriteString(w, "abc")
+		w.Close()
+	}()
+
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	defer f.Close()
+	if _, ok := f.(*closingFile).File.(*reader); !ok {
+		t.Errorf("expected a *reader, got %T", f.(*closingFile).File)
+	}
+	if got := readString(f); got != "abc" {
+		t.Errorf("unexpected content %q", got)
+	}
+	if r, _ := f.Previous(); r != 'c' {
+		t.Errorf("expected 'c', got %q", r)
+	}
+}
@@ -0,0 +1,46 @@
+package rem
+
+import (
+	"context"
+	"net/http"
+)
+
+// defaultPrefetch is the high-water mark of the read-ahead when it is needed by a option but it is not configured.
+const defaultPrefetch = 1 << 12
+
+// Option configures a File created by a constructor that accepts options.
+type Option func(*options)
+
+// options are the configurations of a File.
+type options struct {
+	// prefetch is the high-water mark of the read-ahead. If it is 0 there is no read-ahead.
+	prefetch int64
+	// ctx is the context of the File. It is nil if there is no context.
+	ctx context.Context
+	// compression is the compression format of the input, or nil if it must be detected.
+	compression *Compression
+	// seekIndex is the block size of the seek index. If it is 0 there is no seek index.
+	seekIndex int
+	// client is the HTTP client, or nil for http.DefaultClient.
+	client *http.Client
+	// retries is the number of retries of the HTTP requests, or nil for the default.
+	retries *int
+}
+
+// newOptions returns the options configured by opts.
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithPrefetch makes the File read the input ahead in a goroutine, up to highWater bytes after the current offset, so
+// reading and parsing overlap. It only has effect when the input implements only io.Reader, like pipes and network
+// streams. The errors of the input are returned to the client by the File methods when the client reaches them.
+func WithPrefetch(highWater int64) Option {
+	return func(o *options) {
+		o.prefetch = highWater
+	}
+}
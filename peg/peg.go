@@ -0,0 +1,318 @@
+// This package provides PEG parser combinators that run on a rem.File, with optional packrat memoization.
+package peg
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+
+	"github.com/joaobnv/rem"
+)
+
+// Parser is a parsing expression. If it matches the input at the current offset of the File of c, it returns true
+// and put the offset after the matched text. Otherwise it returns false and the offset remains unchanged.
+type Parser func(c *Context) bool
+
+// memoEntry is the result of a memoized parser at a offset.
+type memoEntry struct {
+	ok  bool
+	end int64
+}
+
+// Context holds the state of a parse.
+type Context struct {
+	// f is the input.
+	f rem.File
+	// memo is the packrat memo table, it maps offsets to the results of the memoized parsers at that offset. It is nil
+	// if the memoization is disabled.
+	memo map[int64]map[uint64]memoEntry
+	// consumed is the offset before which the input was marked as consumed.
+	consumed int64
+	// farthest is the farthest offset at which a terminal failed to match.
+	farthest int64
+}
+
+// NewContext creates a new Context that parses f starting at its current offset. If packrat is true, the results of
+// the parsers created by Memo are stored in a memo table.
+func NewContext(f rem.File, packrat bool) *Context {
+	c := &Context{f: f, consumed: f.Offset(), farthest: f.Offset()}
+	if packrat {
+		c.memo = make(map[int64]map[uint64]memoEntry)
+	}
+	return c
+}
+
+// Parse runs p at the current offset.
+func (c *Context) Parse(p Parser) bool {
+	return p(c)
+}
+
+// File returns the input of c.
+func (c *Context) File() rem.File {
+	return c.f
+}
+
+// Offset returns the current offset.
+func (c *Context) Offset() int64 {
+	return c.f.Offset()
+}
+
+// Farthest returns the farthest offset at which a terminal failed to match. It is useful for error messages.
+func (c *Context) Farthest() int64 {
+	return c.farthest
+}
+
+// Consumed marks the input before offset as consumed and evicts the memo entries before offset. The parsers must not
+// backtrack before offset after this call.
+func (c *Context) Consumed(offset int64) {
+	if offset <= c.consumed {
+		return
+	}
+	c.f.Consumed(offset)
+	c.consumed = offset
+	for off := range c.memo {
+		if off < offset {
+			delete(c.memo, off)
+		}
+	}
+}
+
+// MemoSize returns the number of offsets with entries in the memo table.
+func (c *Context) MemoSize() int {
+	return len(c.memo)
+}
+
+// Text returns the text between the offsets start and end. The current offset remains unchanged.
+func (c *Context) Text(start, end int64) string {
+	offset := c.f.Offset()
+	c.seek(start)
+	p, _ := c.f.ReadBytes(int(end - start))
+	c.seek(offset)
+	return string(p)
+}
+
+// seek put the offset of the input at offset.
+func (c *Context) seek(offset int64) {
+	if offset < c.consumed {
+		panic(errors.New("backtrack before the consumed offset"))
+	}
+	rem.SetOffset(c.f, offset)
+}
+
+// fail records a failure of a terminal at the current offset and returns false.
+func (c *Context) fail() bool {
+	c.farthest = max(c.farthest, c.f.Offset())
+	return false
+}
+
+// Literal matches s.
+func Literal(s string) Parser {
+	return func(c *Context) bool {
+		start := c.f.Offset()
+		for _, er := range s {
+			if r, eof := c.f.Next(); eof || r != er {
+				if !eof {
+					c.f.Previous()
+				}
+				c.fail()
+				c.seek(start)
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Func matches a rune for which fn returns true.
+func Func(fn func(rune) bool) Parser {
+	return func(c *Context) bool {
+		r, eof := c.f.Next()
+		if eof {
+			return c.fail()
+		}
+		if !fn(r) {
+			c.f.Previous()
+			return c.fail()
+		}
+		return true
+	}
+}
+
+// CharClass matches a rune in class. class is a sequence of runes and ranges like "a-zA-Z_". If class starts with '^',
+// CharClass matches a rune that is not in the rest of class.
+func CharClass(class string) Parser {
+	negated := strings.HasPrefix(class, "^")
+	if negated {
+		class = class[1:]
+	}
+	var ranges []rune
+	for rs := []rune(class); len(rs) > 0; {
+		if len(rs) >= 3 && rs[1] == '-' {
+			ranges = append(ranges, rs[0], rs[2])
+			rs = rs[3:]
+		} else {
+			ranges = append(ranges, rs[0], rs[0])
+			rs = rs[1:]
+		}
+	}
+	return Func(func(r rune) bool {
+		for i := 0; i < len(ranges); i += 2 {
+			if ranges[i] <= r && r <= ranges[i+1] {
+				return !negated
+			}
+		}
+		return negated
+	})
+}
+
+// Any matches any rune.
+func Any() Parser {
+	return Func(func(rune) bool { return true })
+}
+
+// EOF matches the end of the input.
+func EOF() Parser {
+	return Not(Any())
+}
+
+// Seq matches the parsers in sequence.
+func Seq(ps ...Parser) Parser {
+	return func(c *Context) bool {
+		start := c.f.Offset()
+		for _, p := range ps {
+			if !p(c) {
+				c.seek(start)
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Choice matches the first of the parsers that matches.
+func Choice(ps ...Parser) Parser {
+	return func(c *Context) bool {
+		for _, p := range ps {
+			if p(c) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Many matches p zero or more times.
+func Many(p Parser) Parser {
+	return func(c *Context) bool {
+		for {
+			start := c.f.Offset()
+			if !p(c) || c.f.Offset() == start {
+				return true
+			}
+		}
+	}
+}
+
+// Many1 matches p one or more times.
+func Many1(p Parser) Parser {
+	return Seq(p, Many(p))
+}
+
+// Optional matches p zero or one time.
+func Optional(p Parser) Parser {
+	return func(c *Context) bool {
+		p(c)
+		return true
+	}
+}
+
+// Not succeeds if p does not match. It never advances the offset.
+func Not(p Parser) Parser {
+	return func(c *Context) bool {
+		start := c.f.Offset()
+		if p(c) {
+			c.seek(start)
+			return false
+		}
+		return true
+	}
+}
+
+// And succeeds if p matches. It never advances the offset.
+func And(p Parser) Parser {
+	return func(c *Context) bool {
+		start := c.f.Offset()
+		if p(c) {
+			c.seek(start)
+			return true
+		}
+		return false
+	}
+}
+
+// Lazy calls fn to get the parser when it is first run. It allows recursive grammars.
+func Lazy(fn func() Parser) Parser {
+	var p Parser
+	return func(c *Context) bool {
+		if p == nil {
+			p = fn()
+		}
+		return p(c)
+	}
+}
+
+// Action matches p and calls fn with the offsets of the matched text.
+func Action(p Parser, fn func(c *Context, start, end int64)) Parser {
+	return func(c *Context) bool {
+		start := c.f.Offset()
+		if !p(c) {
+			return false
+		}
+		fn(c, start, c.f.Offset())
+		return true
+	}
+}
+
+// Consume matches p and marks the input before the end of the matched text as consumed. After that the parsers can not
+// backtrack before the end of the matched text, so it must be used only where backtracking is not needed, like after
+// each statement of a program.
+func Consume(p Parser) Parser {
+	return func(c *Context) bool {
+		if !p(c) {
+			return false
+		}
+		c.Consumed(c.f.Offset())
+		return true
+	}
+}
+
+// memoID is the last identifier given to a memoized parser.
+var memoID atomic.Uint64
+
+// Memo memoizes the results of p by offset if the memoization of the Context is enabled. Note that the actions inside p
+// are not run again when a result is taken from the memo table.
+func Memo(p Parser) Parser {
+	id := memoID.Add(1)
+	return func(c *Context) bool {
+		if c.memo == nil {
+			return p(c)
+		}
+		start := c.f.Offset()
+		if e, ok := c.memo[start][id]; ok {
+			if e.ok {
+				c.seek(e.end)
+			}
+			return e.ok
+		}
+		ok := p(c)
+		if start >= c.consumed {
+			entries := c.memo[start]
+			if entries == nil {
+				entries = make(map[uint64]memoEntry)
+				c.memo[start] = entries
+			}
+			entries[id] = memoEntry{ok: ok, end: c.f.Offset()}
+		}
+		return ok
+	}
+}
@@ -0,0 +1,114 @@
+package peg
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/joaobnv/rem"
+)
+
+// calculator is a grammar of arithmetic expressions that evaluates them.
+type calculator struct {
+	stack []int
+	expr  Parser
+}
+
+// newCalculator creates a new calculator.
+func newCalculator() *calculator {
+	calc := &calculator{}
+	sp := Many(CharClass(" \n"))
+	number := Action(Many1(CharClass("0-9")), func(c *Context, start, end int64) {
+		n, _ := strconv.Atoi(c.Text(start, end))
+		calc.stack = append(calc.stack, n)
+	})
+	binary := func(op func(a, b int) int) func(*Context, int64, int64) {
+		return func(*Context, int64, int64) {
+			l := len(calc.stack)
+			calc.stack = append(calc.stack[:l-2], op(calc.stack[l-2], calc.stack[l-1]))
+		}
+	}
+	var expr Parser
+	primary := Memo(Choice(
+		Seq(number, sp),
+		Seq(Literal("("), sp, Lazy(func() Parser { return expr }), Literal(")"), sp),
+	))
+	product := Seq(primary, Many(Choice(
+		Action(Seq(Literal("*"), sp, primary), binary(func(a, b int) int { return a * b })),
+		Action(Seq(Literal("/"), sp, primary), binary(func(a, b int) int { return a / b })),
+	)))
+	expr = Memo(Seq(product, Many(Choice(
+		Action(Seq(Literal("+"), sp, product), binary(func(a, b int) int { return a + b })),
+		Action(Seq(Literal("-"), sp, product), binary(func(a, b int) int { return a - b })),
+	))))
+	calc.expr = expr
+	return calc
+}
+
+// TestCalculator tests the combinators with a grammar of arithmetic expressions.
+func TestCalculator(t *testing.T) {
+	tests := []struct {
+		input    string
+		ok       bool
+		result   int
+		offset   int64
+		farthest int64
+	}{
+		{"1 + 2 * (3 - 1)", true, 5, 15, 15},
+		{"(1+2)*3 )", true, 9, 8, 8},
+		{"(1+2", false, 0, 0, 4},
+	}
+	for _, packrat := range []bool{false, true} {
+		for _, test := range tests {
+			calc := newCalculator()
+			c := NewContext(rem.NewFile([]byte(test.input)), packrat)
+			ok := c.Parse(calc.expr)
+			if ok != test.ok {
+				t.Errorf("%q: expected ok = %t, got %t", test.input, test.ok, ok)
+			}
+			if ok && calc.stack[len(calc.stack)-1] != test.result {
+				t.Errorf("%q: expected result %d, got %d", test.input, test.result, calc.stack[len(calc.stack)-1])
+			}
+			if c.Offset() != test.offset {
+				t.Errorf("%q: expected offset = %d, got %d", test.input, test.offset, c.Offset())
+			}
+			if c.Farthest() != test.farthest {
+				t.Errorf("%q: expected farthest = %d, got %d", test.input, test.farthest, c.Farthest())
+			}
+		}
+	}
+}
+
+// TestPredicates tests Not, And, Optional and EOF.
+func TestPredicates(t *testing.T) {
+	c := NewContext(rem.NewFile([]byte("abc")), false)
+	if c.Parse(Not(Literal("ab"))) {
+		t.Errorf("expected that Not fails")
+	}
+	if !c.Parse(And(Literal("ab"))) || c.Offset() != 0 {
+		t.Errorf("expected that And succeeds without advancing, offset = %d", c.Offset())
+	}
+	if !c.Parse(Seq(Optional(Literal("x")), Literal("ab"), Not(CharClass("^c")), Any(), EOF())) {
+		t.Errorf("expected that the sequence succeeds")
+	}
+}
+
+// TestConsumeEvictsMemo tests if Consume evicts the memo entries before the consumed offset.
+func TestConsumeEvictsMemo(t *testing.T) {
+	input := strings.Repeat("(1+2)*3;", 100)
+	calc := newCalculator()
+	f := rem.NewFileFromReader(bufio.NewReader(strings.NewReader(input)), 16, 64, ".")
+	defer f.Close()
+	c := NewContext(f, true)
+	program := Seq(Many(Consume(Seq(calc.expr, Literal(";")))), EOF())
+	if !c.Parse(program) {
+		t.Fatalf("expected that the program is parsed, farthest = %d", c.Farthest())
+	}
+	if len(calc.stack) != 100 || calc.stack[99] != 9 {
+		t.Errorf("expected 100 results equal to 9, got %v", calc.stack)
+	}
+	if size := c.MemoSize(); size > 1 {
+		t.Errorf("expected at most 1 memo entry, got %d", size)
+	}
+}
@@ -0,0 +1,141 @@
+package rem
+
+import (
+	"context"
+	"sync"
+)
+
+// prefetch holds the state of the read-ahead of a storage. All fields are guarded by the mutex of the storage.
+type prefetch struct {
+	// highWater is the max number of bytes read ahead of the read offset.
+	highWater int64
+	// cond is used to wait for data, for space, and for the end of the goroutine.
+	cond *sync.Cond
+	// err is the error returned by the input, it is io.EOF at the end of the input.
+	err error
+	// reading indicates whether the goroutine is blocked reading the input.
+	reading bool
+	// closed indicates whether the storage was closed.
+	closed bool
+	// exited indicates whether the goroutine exited.
+	exited bool
+	// ctx is the context of the File, or nil. The waits for data end when it is done.
+	ctx context.Context
+	// stopWake stops the function that wakes the waits when ctx is done.
+	stopWake func() bool
+}
+
+// startPrefetch starts a goroutine that reads the input ahead of the read offset, up to highWater bytes. If ctx is not
+// nil, the waits for data end when ctx is done.
+func (s *storage) startPrefetch(highWater int64, ctx context.Context) {
+	pf := &prefetch{highWater: highWater, cond: sync.NewCond(&s.mu), ctx: ctx}
+	if ctx != nil {
+		pf.stopWake = context.AfterFunc(ctx, func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			pf.cond.Broadcast()
+		})
+	}
+	s.prefetch = pf
+	go s.readAhead()
+}
+
+// readAhead reads the input into the storage until the end of the input, an error, or the storage is closed.
+func (s *storage) readAhead() {
+	pf := s.prefetch
+	buf := make([]byte, min(pf.highWater, 1<<15))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer func() {
+		pf.exited = true
+		pf.cond.Broadcast()
+	}()
+	for {
+		for !pf.closed && s.writeOffset-s.readOffset >= pf.highWater {
+			pf.cond.Wait()
+		}
+		if pf.closed {
+			return
+		}
+		size := min(pf.highWater-(s.writeOffset-s.readOffset), int64(len(buf)))
+
+		pf.reading = true
+		s.mu.Unlock()
+		n, err := s.input.Read(buf[:size])
+		s.mu.Lock()
+		pf.reading = false
+
+		if pf.closed {
+			return
+		}
+		if n > 0 {
+			if _, werr := s.Write(buf[:n]); werr != nil {
+				err = werr
+			}
+		}
+		if err != nil {
+			pf.err = err
+		}
+		pf.cond.Broadcast()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// readPrefetched waits until the goroutine stores bytes after the read offset, or the input returns an error, and then
+// reads the bytes stored. It does not increment the read offset. The caller must hold s.mu.
+func (s *storage) readPrefetched(p []byte) (n int, err error) {
+	pf := s.prefetch
+	// other cursors can be activated while waiting
+	active, readOffset := s.active, s.readOffset
+	for s.writeOffset == readOffset && pf.err == nil && !pf.canceled() {
+		pf.cond.Broadcast()
+		pf.cond.Wait()
+	}
+	if s.active != active {
+		if s.active != nil {
+			s.active.offset = s.readOffset
+		}
+		s.active = active
+	}
+	s.readOffset = readOffset
+
+	if s.writeOffset == s.readOffset {
+		if pf.err == nil {
+			return 0, canceledError(pf.ctx)
+		}
+		return 0, pf.err
+	}
+	if n = s.readFromMemory(p); n > 0 {
+		return n, nil
+	}
+	return s.readFromDisk(p)
+}
+
+// wakePrefetch wakes the goroutine, if there is one, after the read offset advances. The caller must hold s.mu.
+func (s *storage) wakePrefetch() {
+	if s.prefetch != nil {
+		s.prefetch.cond.Broadcast()
+	}
+}
+
+// stopPrefetch stops the goroutine. If the goroutine is blocked reading the input, it exits when the read returns,
+// otherwise stopPrefetch waits for it. The caller must hold s.mu.
+func (s *storage) stopPrefetch() {
+	pf := s.prefetch
+	if pf.stopWake != nil {
+		pf.stopWake()
+	}
+	pf.closed = true
+	pf.cond.Broadcast()
+	for !pf.exited && !pf.reading {
+		pf.cond.Wait()
+	}
+}
+
+// canceled reports whether the context of the File is done.
+func (pf *prefetch) canceled() bool {
+	return pf.ctx != nil && pf.ctx.Err() != nil
+}
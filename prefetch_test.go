@@ -0,0 +1,105 @@
+package rem
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitPrefetch waits until cond is true for the prefetch of r, or fails the test after a timeout.
+func waitPrefetch(t *testing.T, r *reader, cond func(s *storage) bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		r.s.mu.Lock()
+		ok := cond(r.s)
+		r.s.mu.Unlock()
+		if ok {
+			return
+		}
+	}
+	t.Fatalf("timeout waiting for the prefetch")
+}
+
+// TestPrefetch tests the read-ahead of the reader.
+func TestPrefetch(t *testing.T) {
+	data := strings.Repeat("abcdé", 100)
+	pr, pw := io.Pipe()
+	go func() {
+		io.WriteString(pw, data)
+		pw.Close()
+	}()
+
+	f := NewFileFromReader(pr, 1<<6, 1<<12, t.TempDir(), WithPrefetch(100)).(*reader)
+	defer f.Close()
+
+	f.Next()
+	waitPrefetch(t, f, func(s *storage) bool { return s.writeOffset-s.readOffset == 100 })
+	f.ReadBytes(150)
+	waitPrefetch(t, f, func(s *storage) bool { return s.writeOffset == 251 })
+
+	f.Previous()
+	f.Previous()
+	f.Previous()
+	f.Consumed(140)
+	var sb strings.Builder
+	for {
+		r, eof := f.Next()
+		if eof {
+			break
+		}
+		sb.WriteRune(r)
+	}
+	if expected := data[147:]; sb.String() != expected {
+		t.Errorf("expected %q, got %q", expected, sb.String())
+	}
+}
+
+// TestPrefetchError tests if the error of the input is passed on to the client.
+func TestPrefetchError(t *testing.T) {
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Errorf("panic expected")
+			return
+		}
+		if msg := err.(error).Error(); msg != "test" {
+			t.Errorf("expected error message %q, got %q", "test", msg)
+		}
+	}()
+
+	tr := newTestReader([]byte("ab"), errors.New("test"))
+	f := NewFileFromReader(tr, 8, 0, t.TempDir(), WithPrefetch(8))
+	defer f.Close()
+	for _, er := range "ab" {
+		if r, _ := f.Next(); r != er {
+			t.Errorf("expected %q, got %q", er, r)
+		}
+	}
+	f.Next()
+}
+
+// TestPrefetchClose tests if Close stops the goroutine.
+func TestPrefetchClose(t *testing.T) {
+	// the goroutine waits for space
+	f := NewFileFromReader(newTestReader([]byte("abcdef")), 8, 0, t.TempDir(), WithPrefetch(2)).(*reader)
+	waitPrefetch(t, f, func(s *storage) bool { return s.writeOffset == 2 })
+	pf := f.s.prefetch
+	f.Close()
+	if !pf.exited {
+		t.Errorf("expected that the goroutine exited")
+	}
+
+	// the goroutine is blocked reading the input
+	pr, pw := io.Pipe()
+	f = NewFileFromReader(pr, 8, 0, t.TempDir(), WithPrefetch(2)).(*reader)
+	pf = f.s.prefetch
+	waitPrefetch(t, f, func(s *storage) bool { return pf.reading })
+	f.Close()
+	pw.Write([]byte("a"))
+	waitPrefetch(t, f, func(s *storage) bool { return pf.exited })
+	if f.s.writeOffset != 0 {
+		t.Errorf("expected that nothing is written after Close, got writeOffset = %d", f.s.writeOffset)
+	}
+}
@@ -0,0 +1,162 @@
+package rem
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// PushFile is a File whose input is pushed by the client, for example by an event loop that receives the input in chunks.
+// Next returns eof = true when it runs out of fed bytes, or when the fed bytes end in the middle of a rune and the input
+// is not closed. NextStatus tells apart these cases from the end of the input, and NextByteStatus and ReadBytesStatus do
+// the same for NextByte and ReadBytes. The other methods, like SkipUntil and IndexString, only see the bytes fed so far,
+// so they can not tell apart a missing delimiter from one that was not fed yet.
+type PushFile interface {
+	File
+
+	// Feed appends p to the input. It returns an error if the storage limits are exceeded or CloseInput was called.
+	// Feed can be called from a goroutine different from the one that reads the File.
+	Feed(p []byte) error
+
+	// CloseInput marks the end of the input. After that, the end of the fed bytes is the EOF.
+	CloseInput()
+
+	// NextStatus is like Next, but it reports NeedMoreInput instead of EOF if the input was not closed. In this case
+	// the offset remains unchanged. It panics on error.
+	NextStatus() (r rune, status Status)
+
+	// NextByteStatus is like NextByte, but it reports NeedMoreInput instead of EOF if the input was not closed. In
+	// this case the offset remains unchanged. It panics on error.
+	NextByteStatus() (b byte, status Status)
+
+	// ReadBytesStatus is like ReadBytes, but it reports NeedMoreInput if there are less than n bytes fed and the input
+	// was not closed. In this case it returns nil and the offset remains unchanged. If the input was closed, it
+	// returns the rest of the input and EOF if there are less than n bytes. It panics on error.
+	ReadBytesStatus(n int) (p []byte, status Status)
+}
+
+// pushFile implements PushFile using the storage of a reader.
+type pushFile struct {
+	*reader
+	// closed indicates whether CloseInput was called. It is shared with the forks and guarded by the mutex of the
+	// storage.
+	closed *bool
+}
+
+// NewPushFile creates a new PushFile. memLimit is the maximum number of bytes in memory that can be allocated by the File.
+// diskLimit is the maximum number of bytes in disk that can be allocated by the File. tempDir is the directory where
+// disk files will be created. If tempDir is the empty string, the File uses the default directory for temporary files.
+// Like for the io.Reader backend, the File only frees the bytes marked as consumed.
+func NewPushFile(memLimit, diskLimit int64, tempDir string) PushFile {
+	// the input of the storage is always at EOF, the bytes are written into the storage by Feed
+	return &pushFile{reader: newReader(strings.NewReader(""), memLimit, diskLimit, tempDir), closed: new(bool)}
+}
+
+// Feed appends p to the input. It returns an error if the storage limits are exceeded or CloseInput was called.
+func (pf *pushFile) Feed(p []byte) error {
+	s := pf.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if *pf.closed {
+		return errors.New("input is closed")
+	}
+	if len(s.cursors) == 0 {
+		return errors.New("file is closed")
+	}
+	_, err := s.Write(p)
+	return err
+}
+
+// CloseInput marks the end of the input.
+func (pf *pushFile) CloseInput() {
+	pf.s.mu.Lock()
+	defer pf.s.mu.Unlock()
+	*pf.closed = true
+}
+
+// NextStatus is like Next, but it reports NeedMoreInput instead of EOF if the input was not closed.
+func (pf *pushFile) NextStatus() (r rune, status Status) {
+	if status = pf.status(); status != OK {
+		return 0, status
+	}
+	r, _ = pf.reader.Next()
+	return r, OK
+}
+
+// Next returns the rune at the current offset. It returns eof = true if there is not a complete rune in the fed bytes
+// and the input was not closed.
+func (pf *pushFile) Next() (r rune, eof bool) {
+	if pf.status() == NeedMoreInput {
+		return 0, true
+	}
+	return pf.reader.Next()
+}
+
+// NextByteStatus is like NextByte, but it reports NeedMoreInput instead of EOF if the input was not closed.
+func (pf *pushFile) NextByteStatus() (b byte, status Status) {
+	if status = pf.bytesStatus(1); status != OK {
+		return 0, status
+	}
+	b, _ = pf.reader.NextByte()
+	return b, OK
+}
+
+// ReadBytesStatus is like ReadBytes, but it reports NeedMoreInput if there are less than n bytes fed and the input was
+// not closed.
+func (pf *pushFile) ReadBytesStatus(n int) (p []byte, status Status) {
+	if status = pf.bytesStatus(n); status == NeedMoreInput {
+		return nil, status
+	}
+	p, _ = pf.reader.ReadBytes(n)
+	return p, status
+}
+
+// Fork returns a new PushFile that shares the storage and the input of pf, with an independent offset initially equal
+// to the current offset of pf.
+func (pf *pushFile) Fork() File {
+	return &pushFile{reader: pf.reader.Fork().(*reader), closed: pf.closed}
+}
+
+// status returns OK if a complete rune is available at the current offset, or the reason why there is not one.
+func (pf *pushFile) status() Status {
+	s := pf.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pf.activate()
+	p := make([]byte, utf8.UTFMax)
+	n, err := s.Peek(p)
+	if err != nil && err != io.EOF {
+		panic(err)
+	}
+	if utf8.FullRune(p[:n]) {
+		return OK
+	}
+	if !*pf.closed {
+		return NeedMoreInput
+	}
+	if n == 0 {
+		return EOF
+	}
+	// the incomplete rune at the end of the input is reported by Next as invalid
+	return OK
+}
+
+// bytesStatus returns OK if n bytes are available at the current offset, or the reason why they are not.
+func (pf *pushFile) bytesStatus(n int) Status {
+	s := pf.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pf.activate()
+	m, err := s.Peek(make([]byte, n))
+	if err != nil && err != io.EOF {
+		panic(err)
+	}
+	switch {
+	case m == n:
+		return OK
+	case !*pf.closed:
+		return NeedMoreInput
+	}
+	return EOF
+}
@@ -0,0 +1,136 @@
+package rem
+
+import (
+	"testing"
+)
+
+// TestPushFile tests the PushFile.
+func TestPushFile(t *testing.T) {
+	f := NewPushFile(4, 1<<10, t.TempDir())
+	defer f.Close()
+
+	if _, status := f.NextStatus(); status != NeedMoreInput {
+		t.Errorf("expected NeedMoreInput, got %v", status)
+	}
+	if _, eof := f.Next(); !eof {
+		t.Errorf("expected EOF")
+	}
+
+	// "é" is split between two feeds
+	f.Feed([]byte("ab\xc3"))
+	for _, er := range "ab" {
+		if r, status := f.NextStatus(); status != OK || r != er {
+			t.Errorf("expected %q, got %q (%v)", er, r, status)
+		}
+	}
+	if _, status := f.NextStatus(); status != NeedMoreInput {
+		t.Errorf("expected NeedMoreInput, got %v", status)
+	}
+	if f.Offset() != 2 {
+		t.Errorf("expected offset = 2, got %d", f.Offset())
+	}
+	f.Consumed(2)
+
+	f.Feed([]byte("\xa9cdefgh"))
+	f.CloseInput()
+	if err := f.Feed([]byte("i")); err == nil || err.Error() != "input is closed" {
+		t.Errorf("expected error %q, got %v", "input is closed", err)
+	}
+	for _, er := range "écdefgh" {
+		if r, status := f.NextStatus(); status != OK || r != er {
+			t.Errorf("expected %q, got %q (%v)", er, r, status)
+		}
+	}
+	if _, status := f.NextStatus(); status != EOF {
+		t.Errorf("expected EOF, got %v", status)
+	}
+	if r, _ := f.Previous(); r != 'h' {
+		t.Errorf("expected 'h', got %q", r)
+	}
+}
+
+// TestPushFileSplitRune tests if Next waits for the rest of a rune split between feeds, also on a fork.
+func TestPushFileSplitRune(t *testing.T) {
+	f := NewPushFile(4, 1<<10, t.TempDir())
+	defer f.Close()
+
+	f.Feed([]byte("a\xc3"))
+	if r, eof := f.Next(); eof || r != 'a' {
+		t.Errorf("expected 'a', got %q", r)
+	}
+	if _, eof := f.Next(); !eof {
+		t.Errorf("expected EOF")
+	}
+	if offset := f.Offset(); offset != 1 {
+		t.Errorf("unexpected offset %d", offset)
+	}
+	fork := f.Fork()
+	defer fork.Close()
+	if _, eof := fork.Next(); !eof {
+		t.Errorf("expected EOF on the fork")
+	}
+
+	f.Feed([]byte("\xa9"))
+	for _, g := range []File{f, fork} {
+		if r, eof := g.Next(); eof || r != 'é' {
+			t.Errorf("%T: expected 'é', got %q", g, r)
+		}
+		if r, _ := g.Previous(); r != 'é' {
+			t.Errorf("%T: expected 'é', got %q", g, r)
+		}
+	}
+
+	f.Feed([]byte("\xc3"))
+	f.CloseInput()
+	f.Next()
+	defer func() {
+		if err, _ := recover().(error); err == nil || err.Error() != "invalid UTF-8 encoding" {
+			t.Errorf("expected a panic with an invalid UTF-8 encoding, got %v", err)
+		}
+	}()
+	fork.ReadBytes(2)
+	fork.Next()
+}
+
+// TestPushFileBytesStatus tests NextByteStatus and ReadBytesStatus with a length-prefixed field split between feeds.
+func TestPushFileBytesStatus(t *testing.T) {
+	f := NewPushFile(4, 1<<10, t.TempDir())
+	defer f.Close()
+
+	f.Feed([]byte{5, 'a', 'b'})
+	if b, status := f.NextByteStatus(); status != OK || b != 5 {
+		t.Errorf("expected 5, got %d (%v)", b, status)
+	}
+	if p, status := f.ReadBytesStatus(5); status != NeedMoreInput || p != nil {
+		t.Errorf("expected NeedMoreInput, got %q (%v)", p, status)
+	}
+	if f.Offset() != 1 {
+		t.Errorf("expected offset = 1, got %d", f.Offset())
+	}
+
+	f.Feed([]byte("cde"))
+	if p, status := f.ReadBytesStatus(5); status != OK || string(p) != "abcde" {
+		t.Errorf("expected \"abcde\", got %q (%v)", p, status)
+	}
+	if _, status := f.NextByteStatus(); status != NeedMoreInput {
+		t.Errorf("expected NeedMoreInput, got %v", status)
+	}
+
+	f.Feed([]byte("x"))
+	f.CloseInput()
+	if p, status := f.ReadBytesStatus(3); status != EOF || string(p) != "x" {
+		t.Errorf("expected \"x\" and EOF, got %q (%v)", p, status)
+	}
+	if _, status := f.NextByteStatus(); status != EOF {
+		t.Errorf("expected EOF, got %v", status)
+	}
+}
+
+// TestPushFileLimit tests if Feed returns an error when the storage limits are exceeded.
+func TestPushFileLimit(t *testing.T) {
+	f := NewPushFile(2, 0, t.TempDir())
+	defer f.Close()
+	if err := f.Feed([]byte("abc")); err == nil || err.Error() != "storage space has reached the limit" {
+		t.Errorf("expected error %q, got %v", "storage space has reached the limit", err)
+	}
+}
@@ -6,12 +6,15 @@ import (
 	"errors"
 	"io"
 	"os"
-	"slices"
 	"strings"
+	"sync"
 	"unicode/utf8"
 )
 
 // File is a interface that deals with runes.
+//
+// A File is not safe for concurrent use by multiple goroutines, use Synchronized for that. The Files returned by Fork
+// are independent cursors, each one can be used from a different goroutine, even if they share the underlying input.
 type File interface {
 	// Next returns the rune at the current offset, unless the file is at EOF. It panics on error. It put the offset at the start of
 	// the next rune, unless the file is at EOF. In the last case the offset remains unchanged.
@@ -31,6 +34,47 @@ type File interface {
 	// Offset returns the current offset.
 	Offset() int64
 
+	// NextByte returns the byte at the current offset, unless the file is at EOF. It panics on error. It put the offset
+	// after the returned byte, unless the file is at EOF. In the last case the offset remains unchanged. The byte
+	// methods and the rune methods share the same offset, so a client can alternate between them.
+	NextByte() (b byte, eof bool)
+
+	// PreviousByte returns the byte imediately before the current offset, unless the file is on the start of the file.
+	// It panics on error. It put the offset at the returned byte, unless the file is on the start of the file. In the
+	// last case the offset remains unchanged.
+	PreviousByte() (b byte, onStart bool)
+
+	// ReadBytes returns the next n bytes and advances the offset after them. If the file reaches EOF before n bytes
+	// are read, ReadBytes returns the bytes available and eof is true. It panics on error.
+	ReadBytes(n int) (p []byte, eof bool)
+
+	// IndexRune returns the offset of the first instance of r at or after the current offset, or -1 if r is not present.
+	// The current offset remains unchanged. It panics on error.
+	IndexRune(r rune) int64
+
+	// IndexString returns the offset of the first instance of s at or after the current offset, or -1 if s is not
+	// present. The current offset remains unchanged. It panics on error.
+	IndexString(s string) int64
+
+	// LastIndexRune returns the offset of the last instance of r that ends at or before the current offset, or -1 if r
+	// is not present. The current offset remains unchanged. It panics on error.
+	LastIndexRune(r rune) int64
+
+	// LastIndexString returns the offset of the last instance of s that ends at or before the current offset, or -1 if s
+	// is not present. The current offset remains unchanged. It panics on error.
+	LastIndexString(s string) int64
+
+	// SkipUntil put the offset at the start of the first instance of delim at or after the current offset. If delim
+	// is not present, it put the offset at the EOF and found is false. It panics on error.
+	SkipUntil(delim string) (found bool)
+
+	// Begin begins a transaction at the current offset. Transactions can be nested.
+	Begin() Tx
+
+	// Fork returns a new File over the same input with an independent offset, initially equal to the current offset.
+	// The new File has its own transactions and must be closed independently.
+	Fork() File
+
 	// Close releases resources created by File.
 	Close() error
 }
@@ -48,40 +92,73 @@ func NewFileFromString(str string) File {
 // NewFile creates a new File. memLimit is the maximum number of bytes in memory that can be allocated by the File.
 // diskLimit is the maximum number of bytes in disk that can be allocated by the File. tempDir is the directory where
 // disk files will be created. If tempDir is the empty string, the File uses the default directory for temporary files.
-func NewFileFromReader(r io.Reader, memLimit, diskLimit int64, tempDir string) File {
+func NewFileFromReader(r io.Reader, memLimit, diskLimit int64, tempDir string, opts ...Option) File {
+	o := newOptions(opts)
 	if buf, ok := r.(*bytes.Buffer); ok {
 		if memLimit >= int64(buf.Len()) {
 			memLimit = int64(buf.Len())
 			diskLimit = 0
 			tempDir = ""
 		}
-		return newReader(buf, memLimit, diskLimit, tempDir)
+		return withContext(o.ctx, newReader(buf, memLimit, diskLimit, tempDir))
 	}
 	if s, ok := r.(io.ReadSeeker); ok {
-		return newSeeker(s)
+		return withContext(o.ctx, newSeeker(s))
 	}
 	if ra, ok := r.(io.ReaderAt); ok {
-		return newReaderAt(ra)
+		return withContext(o.ctx, newReaderAt(ra))
+	}
+	rd := newReader(r, memLimit, diskLimit, tempDir)
+	if o.ctx != nil && o.prefetch == 0 {
+		o.prefetch = defaultPrefetch
 	}
-	return newReader(r, memLimit, diskLimit, tempDir)
+	if o.prefetch > 0 {
+		rd.s.startPrefetch(o.prefetch, o.ctx)
+	}
+	return withContext(o.ctx, rd)
 }
 
 // reader is a File that uses a input that implements only io.Reader.
 type reader struct {
-	// r is the input.
+	// r is the input. It can be shared with the forks of the reader.
 	s *storage
+	// offset is the current offset. It is valid only if the reader is not the active cursor of s, otherwise the current
+	// offset is the read offset of s.
+	offset int64
+	// tx are the open transactions.
+	tx transactions
 }
 
 // newReader creates a new reader. memLimit is the maximum number of bytes in memory that can be allocated by the reader.
 // diskLimit is the maximum number of bytes in disk that can be allocated by the reader. tempDir is the directory where
 // disk files will be created. If tempDir is the empty string, the reader uses the default directory for temporary files.
 func newReader(r io.Reader, memLimit, diskLimit int64, tempDir string) *reader {
-	return &reader{s: newStorage(r, memLimit, diskLimit, tempDir)}
+	rd := &reader{s: newStorage(r, memLimit, diskLimit, tempDir)}
+	rd.s.active = rd
+	rd.s.cursors[rd] = 0
+	return rd
+}
+
+// activate makes r the active cursor of the storage, this means that the read offset of the storage is the offset of r.
+// Since the storage can be shared by the forks of r, every method of r that uses the read offset must call activate first,
+// holding r.s.mu.
+func (r *reader) activate() {
+	if r.s.active == r {
+		return
+	}
+	if r.s.active != nil {
+		r.s.active.offset = r.s.readOffset
+	}
+	r.s.readOffset = r.offset
+	r.s.active = r
 }
 
 // Next returns the rune at the current offset, unless r is at EOF. It panics on error. It put the offset at the start of
 // the next rune, unless r is at EOF. In the last case the offset remains unchanged.
 func (r *reader) Next() (rn rune, eof bool) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.activate()
 	p := make([]byte, utf8.UTFMax)
 	n, err := r.s.Read(p)
 	if err == io.EOF { // when err == io.EOF the Read method read 0 bytes
@@ -106,6 +183,9 @@ func (r *reader) Next() (rn rune, eof bool) {
 // It put the offset at the start of the previous rune, unless r is on the start of the io.Reader. In the
 // last case the offset remains unchanged.
 func (r *reader) Previous() (rn rune, onStart bool) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.activate()
 	if r.s.onStartRead() {
 		return 0, true
 	}
@@ -119,7 +199,7 @@ func (r *reader) Previous() (rn rune, onStart bool) {
 		}
 
 		if utf8.RuneStart(b[0]) {
-			rn, _ = r.Peek()
+			rn, _ = r.peek()
 			return
 		}
 	}
@@ -129,6 +209,14 @@ func (r *reader) Previous() (rn rune, onStart bool) {
 // Peek returns the next rune but dont advances the reader, this means that if Next is called it will return the same rune.
 // Similarly for the eof.
 func (r *reader) Peek() (rn rune, eof bool) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return r.peek()
+}
+
+// peek is like Peek, but the caller must hold r.s.mu.
+func (r *reader) peek() (rn rune, eof bool) {
+	r.activate()
 	p := make([]byte, utf8.UTFMax)
 	n, err := r.s.Peek(p)
 	if err == io.EOF { // when err == io.EOF the Peek method read 0 bytes
@@ -145,20 +233,176 @@ func (r *reader) Peek() (rn rune, eof bool) {
 	return
 }
 
+// NextByte returns the byte at the current offset, unless r is at EOF. It panics on error. It put the offset after
+// the returned byte, unless r is at EOF. In the last case the offset remains unchanged.
+func (r *reader) NextByte() (b byte, eof bool) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.activate()
+	p := make([]byte, 1)
+	_, err := r.s.Read(p)
+	if err == io.EOF {
+		return 0, true
+	} else if err != nil {
+		panic(err)
+	}
+	return p[0], false
+}
+
+// PreviousByte returns the byte imediately before the current offset, unless r is on the start of the file. It panics
+// on error. It put the offset at the returned byte, unless r is on the start of the file. In the last case the offset
+// remains unchanged.
+func (r *reader) PreviousByte() (b byte, onStart bool) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.activate()
+	if r.s.onStartRead() {
+		return 0, true
+	}
+	r.s.seekRead(-1)
+	p := make([]byte, 1)
+	if _, err := r.s.Peek(p); err != nil {
+		panic(err)
+	}
+	return p[0], false
+}
+
+// ReadBytes returns the next n bytes and advances the offset after them. If r reaches EOF before n bytes are read,
+// ReadBytes returns the bytes available and eof is true. It panics on error.
+func (r *reader) ReadBytes(n int) (p []byte, eof bool) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.activate()
+	p = make([]byte, n)
+	read := 0
+	for read < n {
+		m, err := r.s.Read(p[read:])
+		read += m
+		if err == io.EOF {
+			return p[:read], true
+		} else if err != nil {
+			panic(err)
+		}
+	}
+	return p, false
+}
+
+// IndexRune returns the offset of the first instance of rn at or after the current offset, or -1 if rn is not present.
+// The current offset remains unchanged. It panics on error.
+func (r *reader) IndexRune(rn rune) int64 {
+	return r.IndexString(string(rn))
+}
+
+// IndexString returns the offset of the first instance of str at or after the current offset, or -1 if str is not
+// present. The current offset remains unchanged. It panics on error.
+func (r *reader) IndexString(str string) int64 {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.activate()
+	i, _ := r.s.index([]byte(str))
+	return i
+}
+
+// LastIndexRune returns the offset of the last instance of rn that ends at or before the current offset, or -1 if rn
+// is not present. The current offset remains unchanged. It panics on error.
+func (r *reader) LastIndexRune(rn rune) int64 {
+	return r.LastIndexString(string(rn))
+}
+
+// LastIndexString returns the offset of the last instance of str that ends at or before the current offset, or -1 if
+// str is not present. The current offset remains unchanged. It panics on error.
+func (r *reader) LastIndexString(str string) int64 {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.activate()
+	return r.s.lastIndex([]byte(str))
+}
+
+// SkipUntil put the offset at the start of the first instance of delim at or after the current offset. If delim
+// is not present, it put the offset at the EOF and found is false. It panics on error.
+func (r *reader) SkipUntil(delim string) (found bool) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.activate()
+	i, end := r.s.index([]byte(delim))
+	defer r.s.wakePrefetch()
+	if i < 0 {
+		r.s.readOffset = end
+		return false
+	}
+	r.s.readOffset = i
+	return true
+}
+
 // Consumed marks the bytes before offset as consumed. This means that the reader client no longer needs
 // that r provide access to these bytes. An attempt to access them has an undefined result. offset must be
 // less than or equals the current offset of the reader.
 func (r *reader) Consumed(offset int64) {
-	r.s.Consumed(offset)
+	if offset > r.Offset() {
+		panic(errors.New("invalid offset"))
+	}
+	if !r.tx.deferConsumed(offset) {
+		r.consume(offset)
+	}
+}
+
+// consume marks the bytes before offset as consumed. The storage only reclaims the bytes consumed by all its cursors.
+func (r *reader) consume(offset int64) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.cursors[r] = max(r.s.cursors[r], offset)
+	r.s.reclaim(r.s.minConsumed())
+}
+
+// Begin begins a transaction at the current offset. Transactions can be nested.
+func (r *reader) Begin() Tx {
+	return r.tx.begin(r)
+}
+
+// setOffset put the offset at offset.
+func (r *reader) setOffset(offset int64) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.activate()
+	r.s.readOffset = offset
+}
+
+// Fork returns a new reader that shares the storage of r, with an independent offset initially equal to the current
+// offset of r. The storage only reclaims the bytes consumed by all its cursors.
+func (r *reader) Fork() File {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.activate()
+	fork := &reader{s: r.s, offset: r.s.readOffset}
+	r.s.cursors[fork] = r.s.cursors[r]
+	return fork
 }
 
 // Offset returns the current offset.
 func (r *reader) Offset() int64 {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.activate()
 	return r.s.ReadOffset()
 }
 
-// Close releases resources created by storage.
+// Close releases resources created by storage. If the storage is shared with forks of r, the resources are released
+// when the last of them is closed.
 func (r *reader) Close() error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.cursors[r]; !ok {
+		return nil
+	}
+	delete(r.s.cursors, r)
+	if r.s.active == r {
+		r.s.active.offset = r.s.readOffset
+		r.s.active = nil
+	}
+	if len(r.s.cursors) > 0 {
+		r.s.reclaim(r.s.minConsumed())
+		return nil
+	}
 	return r.s.Close()
 }
 
@@ -194,11 +438,23 @@ type storage struct {
 
 	// tempDir is the directory for temporery files. If it is the empty string, storage uses the default directory for temporary files.
 	tempDir string
+
+	// active is the cursor whose offset is readOffset.
+	active *reader
+
+	// cursors maps the readers that share the storage to the greatest offset each one marked as consumed.
+	cursors map[*reader]int64
+
+	// mu guards the storage, so the readers that share it can be used from different goroutines.
+	mu sync.Mutex
+
+	// prefetch is the state of the read-ahead. It is nil if there is no read-ahead.
+	prefetch *prefetch
 }
 
 // newStorage creates a new storage.
 func newStorage(r io.Reader, memLimit, diskLimit int64, tempDir string) *storage {
-	return &storage{input: r, memLimit: memLimit, diskLimit: diskLimit, tempDir: tempDir}
+	return &storage{input: r, memLimit: memLimit, diskLimit: diskLimit, tempDir: tempDir, cursors: make(map[*reader]int64)}
 }
 
 // Read implements io.Reader.
@@ -208,6 +464,7 @@ func (s *storage) Read(p []byte) (n int, err error) {
 		return
 	}
 	s.readOffset += int64(n)
+	s.wakePrefetch()
 	return
 }
 
@@ -244,26 +501,59 @@ func (s *storage) Peek(p []byte) (n int, err error) {
 	return n + n2 + n3, err
 }
 
-// readFromMemory reads from memory. It dont increments the read offset.
-func (s *storage) readFromMemory(p []byte) (n int) {
-	avaliable := int64(len(s.mem)) - s.memoryOffset(s.readOffset)
-	if avaliable >= int64(len(p)) {
-		for i := range p {
-			p[i] = s.mem[int64(i)+s.readOffset]
+// ReadAt implements io.ReaderAt. It reads from the input if necessary, but the read offset remains unchanged.
+func (s *storage) ReadAt(p []byte, off int64) (n int, err error) {
+	readOffset := s.readOffset
+	defer func() { s.readOffset = readOffset }()
+	s.readOffset = off
+	for n < len(p) {
+		var m int
+		m, err = s.Read(p[n:])
+		n += m
+		if err != nil {
+			return
 		}
-		return len(p)
-	} else if avaliable > 0 {
-		for i := range avaliable {
-			p[i] = s.mem[i+s.readOffset]
+	}
+	return
+}
+
+// index returns the offset of the first instance of sep at or after the read offset, or -1 if sep is not present.
+// If sep is not present, end is the offset of the EOF. The bytes on memory are searched directly. It panics on error.
+func (s *storage) index(sep []byte) (i, end int64) {
+	offset := s.readOffset
+	if memOff := s.memoryOffset(offset); memOff < int64(len(s.mem)) {
+		if i := bytes.Index(s.mem[memOff:], sep); i >= 0 {
+			return offset + int64(i), -1
 		}
-		return int(avaliable)
+		offset = max(offset, s.startOffset+int64(len(s.mem)-len(sep)+1))
 	}
-	return 0
+	return indexAt(s, offset, sep)
+}
+
+// lastIndex returns the offset of the last instance of sep that ends at or before the read offset, or -1 if sep is not
+// present. If the read offset is on memory, the bytes on memory are searched directly. It panics on error.
+func (s *storage) lastIndex(sep []byte) int64 {
+	if memOff := s.memoryOffset(s.readOffset); memOff <= int64(len(s.mem)) {
+		if i := bytes.LastIndex(s.mem[:memOff], sep); i >= 0 {
+			return s.startOffset + int64(i)
+		}
+		return -1
+	}
+	return lastIndexAt(s, s.startOffset, s.readOffset, sep)
+}
+
+// readFromMemory reads from memory. It dont increments the read offset.
+func (s *storage) readFromMemory(p []byte) (n int) {
+	memOff := s.memoryOffset(s.readOffset)
+	if memOff >= int64(len(s.mem)) {
+		return 0
+	}
+	return copy(p, s.mem[memOff:])
 }
 
 // readFromDisk reads from disk. It dont increments the read offset.
 func (s *storage) readFromDisk(p []byte) (n int, err error) {
-	if s.disk == nil {
+	if s.disk == nil || s.readOffset < s.startOffset+s.memLimit {
 		return 0, io.EOF
 	}
 	return s.disk.ReadAt(p, s.diskOffset(s.readOffset))
@@ -271,6 +561,9 @@ func (s *storage) readFromDisk(p []byte) (n int, err error) {
 
 // readFromInput reads from the input.
 func (s *storage) readFromInput(p []byte) (n int, err error) {
+	if s.prefetch != nil {
+		return s.readPrefetched(p)
+	}
 	n, err = s.input.Read(p)
 	if n == 0 {
 		return
@@ -285,33 +578,51 @@ func (s *storage) Consumed(offset int64) {
 	if offset > s.readOffset {
 		panic(errors.New("invalid offset"))
 	}
-	if offset-s.startOffset >= s.memLimit {
+	s.reclaim(offset)
+}
+
+// reclaim reuses the memory and the disk space of the bytes before offset.
+func (s *storage) reclaim(offset int64) {
+	for s.memLimit > 0 && offset-s.startOffset >= s.memLimit {
 		s.moveToMemory()
 	}
 }
 
-// moveToMemory move bytes from s.disk to s.mem.
+// minConsumed returns the least of the offsets consumed by the cursors of s.
+func (s *storage) minConsumed() int64 {
+	consumed := int64(-1)
+	for _, c := range s.cursors {
+		if consumed < 0 || c < consumed {
+			consumed = c
+		}
+	}
+	return consumed
+}
+
+// moveToMemory discards the bytes in s.mem and move bytes from s.disk to s.mem.
 func (s *storage) moveToMemory() {
-	if s.disk == nil {
+	memEnd := s.startOffset + int64(len(s.mem))
+	if s.disk == nil || s.writeOffset == memEnd {
+		// there is no bytes on disk
+		s.startOffset = memEnd
+		s.mem = s.mem[:0]
 		return
 	}
+
+	diskEnd := s.diskOffset(s.writeOffset)
+	if int64(cap(s.mem)) < s.memLimit {
+		s.mem = make([]byte, s.memLimit)
+	}
+	s.mem = s.mem[:s.memLimit]
 	sr := io.NewSectionReader(s.disk, s.diskStart, s.memLimit)
 	n, err := io.ReadFull(sr, s.mem)
-	if err == io.EOF || err == io.ErrUnexpectedEOF {
-		if err = s.disk.Truncate(0); err != nil {
-			panic(err)
-		}
-		s.diskStart = 0
-	} else if err != nil {
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
 		panic(err)
 	}
+	s.mem = s.mem[:n]
+	s.startOffset += s.memLimit
 
-	if n < len(s.mem) {
-		s.mem = s.mem[:n]
-		s.mem = slices.Clip(s.mem)
-	}
-
-	if s.diskStart+int64(n) == s.diskOffset(s.writeOffset) {
+	if err != nil || s.diskStart+int64(n) == diskEnd {
 		if err = s.disk.Truncate(0); err != nil {
 			panic(err)
 		}
@@ -319,7 +630,6 @@ func (s *storage) moveToMemory() {
 	} else {
 		s.diskStart += int64(n)
 	}
-
 }
 
 // seekRead seek the read offset from the current position.
@@ -371,6 +681,12 @@ func (s *storage) writeIntoDisk(p []byte) (n int, err error) {
 		}
 	}
 	avaliableDisk := s.diskLimit - s.diskOffset(s.writeOffset)
+	if avaliableDisk < int64(len(p)) && s.diskStart > 0 {
+		if err = s.compactDisk(); err != nil {
+			return
+		}
+		avaliableDisk = s.diskLimit - s.diskOffset(s.writeOffset)
+	}
 	if avaliableDisk >= int64(len(p)) {
 		n, err = s.disk.WriteAt(p, s.diskOffset(s.writeOffset))
 		s.writeOffset += int64(n)
@@ -379,6 +695,33 @@ func (s *storage) writeIntoDisk(p []byte) (n int, err error) {
 	return 0, errors.New("storage space has reached the limit")
 }
 
+// compactDisk moves the bytes on disk to the start of s.disk, so the space of the bytes that were moved to memory
+// can be reused.
+func (s *storage) compactDisk() error {
+	diskEnd := s.diskOffset(s.writeOffset)
+	buf := make([]byte, min(diskEnd-s.diskStart, 1<<15))
+	var dst int64
+	for src := s.diskStart; src < diskEnd; {
+		n, err := s.disk.ReadAt(buf[:min(int64(len(buf)), diskEnd-src)], src)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if _, err = s.disk.WriteAt(buf[:n], dst); err != nil {
+			return err
+		}
+		src += int64(n)
+		dst += int64(n)
+		if n == 0 {
+			break
+		}
+	}
+	if err := s.disk.Truncate(dst); err != nil {
+		return err
+	}
+	s.diskStart = 0
+	return nil
+}
+
 // memoryOffset returns the offset from the start of s.mem corresponding to inputOffset.
 func (s *storage) memoryOffset(inputOffset int64) int64 {
 	result := inputOffset - s.startOffset
@@ -410,6 +753,9 @@ func (s *storage) createDisk() (err error) {
 
 // Close removes the created temporary file if there is any, and frees up used memory.
 func (s *storage) Close() error {
+	if s.prefetch != nil {
+		s.stopPrefetch()
+	}
 	s.mem = nil
 	if s.disk == nil || s.disk == (*os.File)(nil) {
 		return nil
@@ -433,16 +779,22 @@ type disk interface {
 type seeker struct {
 	// rs is the input.
 	rs io.ReadSeeker
+	// mu guards rs. It is shared with the forks of the seeker that read through rs.
+	mu *sync.Mutex
+	// tx are the open transactions.
+	tx transactions
 }
 
 // newSeeker creates a new seeker.
 func newSeeker(rs io.ReadSeeker) *seeker {
-	return &seeker{rs}
+	return &seeker{rs: rs, mu: new(sync.Mutex)}
 }
 
 // Next returns the rune at the current offset, unless s is at EOF. It panics on error. It put the offset at the start of
 // the next rune, unless s is at EOF. In the last case the offset remains unchanged.
 func (s *seeker) Next() (rn rune, eof bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	p := make([]byte, utf8.UTFMax)
 	n, err := io.ReadFull(s.rs, p)
 	if err == io.EOF {
@@ -467,11 +819,13 @@ func (s *seeker) Next() (rn rune, eof bool) {
 // It put the offset at the start of the previous rune, unless s is on the start of the io.Reader. In the
 // last case the offset remains unchanged.
 func (s *seeker) Previous() (r rune, onStart bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	if s.isOnStart() {
 		return 0, true
 	}
 
-	offset := s.Offset()
+	offset := s.offset()
 	for {
 		offset--
 		if offset == -1 {
@@ -484,7 +838,7 @@ func (s *seeker) Previous() (r rune, onStart bool) {
 		b, _ := s.peekByte()
 
 		if utf8.RuneStart(b) {
-			r, _ = s.Peek()
+			r, _ = s.peek()
 			return
 		}
 
@@ -495,6 +849,13 @@ func (s *seeker) Previous() (r rune, onStart bool) {
 // Peek returns the next rune but dont advances the seeker, this means that if Next is called it will return the same rune.
 // Similarly for the eof.
 func (s *seeker) Peek() (r rune, eof bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.peek()
+}
+
+// peek is like Peek, but the caller must hold s.mu.
+func (s *seeker) peek() (r rune, eof bool) {
 	p := make([]byte, utf8.UTFMax)
 	n, err := io.ReadFull(s.rs, p)
 	if err == io.EOF {
@@ -532,9 +893,110 @@ func (s *seeker) peekByte() (b byte, eof bool) {
 	return p[0], false
 }
 
+// NextByte returns the byte at the current offset, unless s is at EOF. It panics on error. It put the offset after
+// the returned byte, unless s is at EOF. In the last case the offset remains unchanged.
+func (s *seeker) NextByte() (b byte, eof bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := make([]byte, 1)
+	_, err := io.ReadFull(s.rs, p)
+	if err == io.EOF {
+		return 0, true
+	} else if err != nil {
+		panic(err)
+	}
+	return p[0], false
+}
+
+// PreviousByte returns the byte imediately before the current offset, unless s is on the start of the file. It panics
+// on error. It put the offset at the returned byte, unless s is on the start of the file. In the last case the offset
+// remains unchanged.
+func (s *seeker) PreviousByte() (b byte, onStart bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.isOnStart() {
+		return 0, true
+	}
+	if _, err := s.rs.Seek(-1, io.SeekCurrent); err != nil {
+		panic(err)
+	}
+	b, _ = s.peekByte()
+	return
+}
+
+// ReadBytes returns the next n bytes and advances the offset after them. If s reaches EOF before n bytes are read,
+// ReadBytes returns the bytes available and eof is true. It panics on error.
+func (s *seeker) ReadBytes(n int) (p []byte, eof bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p = make([]byte, n)
+	m, err := io.ReadFull(s.rs, p)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return p[:m], true
+	} else if err != nil {
+		panic(err)
+	}
+	return p, false
+}
+
+// IndexRune returns the offset of the first instance of r at or after the current offset, or -1 if r is not present.
+// The current offset remains unchanged. It panics on error.
+func (s *seeker) IndexRune(r rune) int64 {
+	return s.IndexString(string(r))
+}
+
+// IndexString returns the offset of the first instance of str at or after the current offset, or -1 if str is not
+// present. The current offset remains unchanged. The input is read in blocks. It panics on error.
+func (s *seeker) IndexString(str string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	offset := s.offset()
+	i, _ := indexAt(seekerAt{rs: s.rs}, offset, []byte(str))
+	s.seek(offset)
+	return i
+}
+
+// LastIndexRune returns the offset of the last instance of r that ends at or before the current offset, or -1 if r
+// is not present. The current offset remains unchanged. It panics on error.
+func (s *seeker) LastIndexRune(r rune) int64 {
+	return s.LastIndexString(string(r))
+}
+
+// LastIndexString returns the offset of the last instance of str that ends at or before the current offset, or -1 if
+// str is not present. The current offset remains unchanged. The input is read in blocks. It panics on error.
+func (s *seeker) LastIndexString(str string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	offset := s.offset()
+	i := lastIndexAt(seekerAt{rs: s.rs}, 0, offset, []byte(str))
+	s.seek(offset)
+	return i
+}
+
+// SkipUntil put the offset at the start of the first instance of delim at or after the current offset. If delim
+// is not present, it put the offset at the EOF and found is false. It panics on error.
+func (s *seeker) SkipUntil(delim string) (found bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i, end := indexAt(seekerAt{rs: s.rs}, s.offset(), []byte(delim))
+	if i < 0 {
+		s.seek(end)
+		return false
+	}
+	s.seek(i)
+	return true
+}
+
+// seek put the offset at offset. It panics on error.
+func (s *seeker) seek(offset int64) {
+	if _, err := s.rs.Seek(offset, io.SeekStart); err != nil {
+		panic(err)
+	}
+}
+
 // isOnStart reports whether the offset is at the start of the input.
 func (s *seeker) isOnStart() bool {
-	return s.Offset() == 0
+	return s.offset() == 0
 }
 
 // Consumed marks the bytes before offset as consumed. This means that the seeker client no longer needs
@@ -546,8 +1008,42 @@ func (s *seeker) Consumed(offset int64) {
 	}
 }
 
+// consume is a no-op, because s does not free resources when the bytes are consumed.
+func (s *seeker) consume(offset int64) {}
+
+// Begin begins a transaction at the current offset. Transactions can be nested.
+func (s *seeker) Begin() Tx {
+	return s.tx.begin(s)
+}
+
+// setOffset put the offset at offset.
+func (s *seeker) setOffset(offset int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seek(offset)
+}
+
+// Fork returns a new File over the input of s with an independent offset initially equal to the current offset of s.
+// If the input implements io.ReaderAt the new File uses it, otherwise the new File reads through the io.ReadSeeker,
+// holding the lock of s and restoring the seek position after each read.
+func (s *seeker) Fork() File {
+	fork := newReaderAt(seekerAt{rs: s.rs, mu: s.mu})
+	if ra, ok := s.rs.(io.ReaderAt); ok {
+		fork = newReaderAt(ra)
+	}
+	fork.offset = s.Offset()
+	return fork
+}
+
 // Offset returns the current offset.
 func (s *seeker) Offset() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.offset()
+}
+
+// offset is like Offset, but the caller must hold s.mu.
+func (s *seeker) offset() int64 {
 	offset, err := s.rs.Seek(0, io.SeekCurrent)
 	if err != nil {
 		panic(err)
@@ -566,6 +1062,8 @@ type readerAt struct {
 	ra io.ReaderAt
 	// offset is the current offset.
 	offset int64
+	// tx are the open transactions.
+	tx transactions
 }
 
 // newReaderAt creates a new readerAt.
@@ -636,6 +1134,84 @@ func (ra *readerAt) Peek() (rn rune, eof bool) {
 	return
 }
 
+// NextByte returns the byte at the current offset, unless ra is at EOF. It panics on error. It put the offset after
+// the returned byte, unless ra is at EOF. In the last case the offset remains unchanged.
+func (ra *readerAt) NextByte() (b byte, eof bool) {
+	p := make([]byte, 1)
+	n, err := ra.ra.ReadAt(p, ra.offset)
+	if n == 0 && err == io.EOF {
+		return 0, true
+	} else if n == 0 && err != nil {
+		panic(err)
+	}
+	ra.offset++
+	return p[0], false
+}
+
+// PreviousByte returns the byte imediately before the current offset, unless ra is on the start of the input. It
+// panics on error. It put the offset at the returned byte, unless ra is on the start of the input. In the last case
+// the offset remains unchanged.
+func (ra *readerAt) PreviousByte() (b byte, onStart bool) {
+	if ra.offset == 0 {
+		return 0, true
+	}
+	p := make([]byte, 1)
+	if _, err := ra.ra.ReadAt(p, ra.offset-1); err != nil && err != io.EOF {
+		panic(err)
+	}
+	ra.offset--
+	return p[0], false
+}
+
+// ReadBytes returns the next n bytes and advances the offset after them. If ra reaches EOF before n bytes are read,
+// ReadBytes returns the bytes available and eof is true. It panics on error.
+func (ra *readerAt) ReadBytes(n int) (p []byte, eof bool) {
+	p = make([]byte, n)
+	m, err := ra.ra.ReadAt(p, ra.offset)
+	if err != nil && err != io.EOF {
+		panic(err)
+	}
+	ra.offset += int64(m)
+	return p[:m], m < n
+}
+
+// IndexRune returns the offset of the first instance of r at or after the current offset, or -1 if r is not present.
+// The current offset remains unchanged. It panics on error.
+func (ra *readerAt) IndexRune(r rune) int64 {
+	return ra.IndexString(string(r))
+}
+
+// IndexString returns the offset of the first instance of s at or after the current offset, or -1 if s is not
+// present. The current offset remains unchanged. The input is read in blocks. It panics on error.
+func (ra *readerAt) IndexString(s string) int64 {
+	i, _ := indexAt(ra.ra, ra.offset, []byte(s))
+	return i
+}
+
+// LastIndexRune returns the offset of the last instance of r that ends at or before the current offset, or -1 if r
+// is not present. The current offset remains unchanged. It panics on error.
+func (ra *readerAt) LastIndexRune(r rune) int64 {
+	return ra.LastIndexString(string(r))
+}
+
+// LastIndexString returns the offset of the last instance of s that ends at or before the current offset, or -1 if s
+// is not present. The current offset remains unchanged. The input is read in blocks. It panics on error.
+func (ra *readerAt) LastIndexString(s string) int64 {
+	return lastIndexAt(ra.ra, 0, ra.offset, []byte(s))
+}
+
+// SkipUntil put the offset at the start of the first instance of delim at or after the current offset. If delim
+// is not present, it put the offset at the EOF and found is false. It panics on error.
+func (ra *readerAt) SkipUntil(delim string) (found bool) {
+	i, end := indexAt(ra.ra, ra.offset, []byte(delim))
+	if i < 0 {
+		ra.offset = end
+		return false
+	}
+	ra.offset = i
+	return true
+}
+
 // Consumed marks the bytes before offset as consumed. This means that the readerAt client no longer needs
 // that ra provide access to these bytes. An attempt to access them has an undefined result. offset must be
 // less than or equals the current offset of the readerAt.
@@ -645,6 +1221,25 @@ func (ra *readerAt) Consumed(offset int64) {
 	}
 }
 
+// consume is a no-op, because ra does not free resources when the bytes are consumed.
+func (ra *readerAt) consume(offset int64) {}
+
+// Begin begins a transaction at the current offset. Transactions can be nested.
+func (ra *readerAt) Begin() Tx {
+	return ra.tx.begin(ra)
+}
+
+// setOffset put the offset at offset.
+func (ra *readerAt) setOffset(offset int64) {
+	ra.offset = offset
+}
+
+// Fork returns a new readerAt over the same io.ReaderAt with an independent offset initially equal to the current
+// offset of ra.
+func (ra *readerAt) Fork() File {
+	return &readerAt{ra: ra.ra, offset: ra.offset}
+}
+
 // Offset returns the current offset.
 func (ra *readerAt) Offset() int64 {
 	return ra.offset
@@ -661,6 +1256,8 @@ type bytesFile struct {
 	b []byte
 	// offset is the current offset.
 	offset int64
+	// tx are the open transactions.
+	tx transactions
 }
 
 // newBytesFile creates a new bytesFile.
@@ -707,6 +1304,80 @@ func (bf *bytesFile) Previous() (r rune, onStart bool) {
 	panic(errors.New("invalid UTF-8 encoding"))
 }
 
+// NextByte returns the byte at the current offset, unless bf is at EOF. It put the offset after the returned byte,
+// unless bf is at EOF. In the last case the offset remains unchanged.
+func (bf *bytesFile) NextByte() (b byte, eof bool) {
+	if bf.offset == int64(len(bf.b)) {
+		return 0, true
+	}
+	b = bf.b[bf.offset]
+	bf.offset++
+	return
+}
+
+// PreviousByte returns the byte imediately before the current offset, unless bf is on the start of the input. It put
+// the offset at the returned byte, unless bf is on the start of the input. In the last case the offset remains unchanged.
+func (bf *bytesFile) PreviousByte() (b byte, onStart bool) {
+	if bf.offset == 0 {
+		return 0, true
+	}
+	bf.offset--
+	return bf.b[bf.offset], false
+}
+
+// ReadBytes returns the next n bytes and advances the offset after them. If bf reaches EOF before n bytes are read,
+// ReadBytes returns the bytes available and eof is true. The returned slice is a copy of the input.
+func (bf *bytesFile) ReadBytes(n int) (p []byte, eof bool) {
+	end := bf.offset + int64(n)
+	if end > int64(len(bf.b)) {
+		end = int64(len(bf.b))
+		eof = true
+	}
+	p = bytes.Clone(bf.b[bf.offset:end])
+	bf.offset = end
+	return
+}
+
+// IndexRune returns the offset of the first instance of r at or after the current offset, or -1 if r is not present.
+// The current offset remains unchanged.
+func (bf *bytesFile) IndexRune(r rune) int64 {
+	return bf.IndexString(string(r))
+}
+
+// IndexString returns the offset of the first instance of s at or after the current offset, or -1 if s is not
+// present. The current offset remains unchanged.
+func (bf *bytesFile) IndexString(s string) int64 {
+	i := bytes.Index(bf.b[bf.offset:], []byte(s))
+	if i < 0 {
+		return -1
+	}
+	return bf.offset + int64(i)
+}
+
+// LastIndexRune returns the offset of the last instance of r that ends at or before the current offset, or -1 if r
+// is not present. The current offset remains unchanged.
+func (bf *bytesFile) LastIndexRune(r rune) int64 {
+	return bf.LastIndexString(string(r))
+}
+
+// LastIndexString returns the offset of the last instance of s that ends at or before the current offset, or -1 if s
+// is not present. The current offset remains unchanged.
+func (bf *bytesFile) LastIndexString(s string) int64 {
+	return int64(bytes.LastIndex(bf.b[:bf.offset], []byte(s)))
+}
+
+// SkipUntil put the offset at the start of the first instance of delim at or after the current offset. If delim
+// is not present, it put the offset at the EOF and found is false.
+func (bf *bytesFile) SkipUntil(delim string) (found bool) {
+	i := bf.IndexString(delim)
+	if i < 0 {
+		bf.offset = int64(len(bf.b))
+		return false
+	}
+	bf.offset = i
+	return true
+}
+
 // Consumed marks the bytes before offset as consumed. This means that the readerAt client no longer needs
 // that bf provide access to these bytes. An attempt to access them has an undefined result. offset must be
 // less than or equals the current offset of the bytesFile.
@@ -716,6 +1387,25 @@ func (bf *bytesFile) Consumed(offset int64) {
 	}
 }
 
+// consume is a no-op, because bf does not free resources when the bytes are consumed.
+func (bf *bytesFile) consume(offset int64) {}
+
+// Begin begins a transaction at the current offset. Transactions can be nested.
+func (bf *bytesFile) Begin() Tx {
+	return bf.tx.begin(bf)
+}
+
+// setOffset put the offset at offset.
+func (bf *bytesFile) setOffset(offset int64) {
+	bf.offset = offset
+}
+
+// Fork returns a new bytesFile over the same byte slice with an independent offset initially equal to the current
+// offset of bf.
+func (bf *bytesFile) Fork() File {
+	return &bytesFile{b: bf.b, offset: bf.offset}
+}
+
 // Offset returns the current offset.
 func (bf *bytesFile) Offset() int64 {
 	return bf.offset
@@ -412,6 +412,33 @@ func TestReaderConsumed(t *testing.T) {
 	}
 }
 
+// TestReaderConsumedStream tests if the reader keeps providing the right runes when the bytes are consumed while the
+// input is read.
+func TestReaderConsumedStream(t *testing.T) {
+	data := strings.Repeat("0123456789", 100)
+	f := NewFileFromReader(bufio.NewReader(strings.NewReader(data)), 8, 32, t.TempDir()).(*reader)
+	defer f.Close()
+	for i, er := range data {
+		r, eof := f.Next()
+		if eof {
+			t.Fatalf("unexpected EOF at %d", i)
+		}
+		if r != er {
+			t.Fatalf("expected %q at %d, got %q", er, i, r)
+		}
+		if i%10 == 9 {
+			if r, _ := f.Previous(); r != '9' {
+				t.Fatalf("expected '9' at %d, got %q", i, r)
+			}
+			f.Next()
+			f.Consumed(int64(i - 3))
+		}
+	}
+	if f.s.startOffset == 0 {
+		t.Errorf("expected that the memory was reused")
+	}
+}
+
 // TestSeekReadLessThanReadOffset tests if the seekRead method of storage makes the readOffset equals 0 if the seek will make
 // they less than 0.
 func TestSeekReadLessThanReadOffset(t *testing.T) {
@@ -964,6 +991,319 @@ func TestPanicBytesPrevious(t *testing.T) {
 	f.Previous()
 }
 
+// TestBytes tests the byte methods of File and their interaction with the rune methods.
+func TestBytes(t *testing.T) {
+	data := "\x00\x03ab\xffé"
+	files := []File{
+		NewFile([]byte(data)),
+		NewFileFromString(data),
+		NewFileFromReader(bytes.NewBuffer([]byte(data)), 2, 1<<10, "."),
+		NewFileFromReader(strings.NewReader(data), 2, 1<<10, "."),
+		NewFileFromReader(newTestReaderAt(data), 2, 1<<10, "."),
+		NewFileFromReader(bufio.NewReader(strings.NewReader(data)), 2, 1<<10, "."),
+	}
+
+	defer func() {
+		for i := range files {
+			files[i].Close()
+		}
+	}()
+
+	for _, f := range files {
+		if _, onStart := f.PreviousByte(); !onStart {
+			t.Errorf("%T: expected onStart", f)
+		}
+
+		if b, eof := f.NextByte(); eof || b != 0 {
+			t.Errorf("%T: expected 0x00, got %#x (eof = %t)", f, b, eof)
+		}
+		n, _ := f.NextByte()
+		p, eof := f.ReadBytes(int(n))
+		if eof || string(p) != "ab\xff" {
+			t.Errorf("%T: expected %q, got %q (eof = %t)", f, "ab\xff", p, eof)
+		}
+		if f.Offset() != 5 {
+			t.Errorf("%T: expected offset = 5, got %d", f, f.Offset())
+		}
+
+		if r, eof := f.Next(); eof || r != 'é' {
+			t.Errorf("%T: expected %q, got %q (eof = %t)", f, 'é', r, eof)
+		}
+		if _, eof := f.NextByte(); !eof {
+			t.Errorf("%T: expected EOF", f)
+		}
+		if p, eof := f.ReadBytes(2); !eof || len(p) != 0 {
+			t.Errorf("%T: expected EOF and no bytes, got %q (eof = %t)", f, p, eof)
+		}
+
+		if r, onStart := f.Previous(); onStart || r != 'é' {
+			t.Errorf("%T: expected %q, got %q (onStart = %t)", f, 'é', r, onStart)
+		}
+		if b, onStart := f.PreviousByte(); onStart || b != 0xff {
+			t.Errorf("%T: expected 0xff, got %#x (onStart = %t)", f, b, onStart)
+		}
+		if b, onStart := f.PreviousByte(); onStart || b != 'b' {
+			t.Errorf("%T: expected 'b', got %#x (onStart = %t)", f, b, onStart)
+		}
+		if f.Offset() != 3 {
+			t.Errorf("%T: expected offset = 3, got %d", f, f.Offset())
+		}
+
+		p, eof = f.ReadBytes(10)
+		if !eof || string(p) != "b\xffé" {
+			t.Errorf("%T: expected %q, got %q (eof = %t)", f, "b\xffé", p, eof)
+		}
+	}
+}
+
+// TestPanicReaderAtNextByteReadError tests if the NextByte method of readerAt panics if the io.ReaderAt returns
+// a error diferent from io.EOF.
+func TestPanicReaderAtNextByteReadError(t *testing.T) {
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Errorf("panic expected")
+			return
+		}
+		if msg := err.(error).Error(); msg != "test" {
+			t.Errorf("expected error message %q, got %q", "test", msg)
+		}
+	}()
+
+	f := NewFileFromReader(newTestReaderAt("test", errors.New("test")), 1, 1, ".")
+	f.NextByte()
+}
+
+// TestPanicSeekerPreviousByteSeekError tests if the PreviousByte method of seeker panics if the Seek method returns a error.
+func TestPanicSeekerPreviousByteSeekError(t *testing.T) {
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Errorf("panic expected")
+			return
+		}
+		if msg := err.(error).Error(); msg != "test" {
+			t.Errorf("expected error message %q, got %q", "test", msg)
+		}
+	}()
+
+	tr := newTestReadSeeker([]any{[]byte("test")}, []any{[]byte("test")})
+	f := NewFileFromReader(tr, 4, 1, ".")
+	f.NextByte()
+	tr.setSeekData(errors.New("test"), []byte("t"))
+	f.PreviousByte()
+}
+
+// TestPanicReaderReadBytesError tests if the ReadBytes method of reader panics if the io.Reader returns a error
+// diferent from io.EOF.
+func TestPanicReaderReadBytesError(t *testing.T) {
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Errorf("panic expected")
+			return
+		}
+		if msg := err.(error).Error(); msg != "test" {
+			t.Errorf("expected error message %q, got %q", "test", msg)
+		}
+	}()
+
+	tr := newTestReader([]byte("te"), errors.New("test"))
+	f := NewFileFromReader(tr, 8, 0, ".")
+	f.ReadBytes(4)
+}
+
+// TestSearch tests the search methods of File.
+func TestSearch(t *testing.T) {
+	// the delimiters cross the boundaries of the blocks read by the searches
+	data := strings.Repeat("a", searchBlockSize-2) + "*/" + strings.Repeat("b", searchBlockSize) + "é*/c"
+	files := []File{
+		NewFile([]byte(data)),
+		NewFileFromString(data),
+		NewFileFromReader(bytes.NewBuffer([]byte(data)), 1<<6, 1<<14, "."),
+		NewFileFromReader(strings.NewReader(data), 1<<6, 1<<14, "."),
+		NewFileFromReader(newTestReaderAt(data), 1<<6, 1<<14, "."),
+		NewFileFromReader(bufio.NewReader(strings.NewReader(data)), 1<<6, 1<<14, "."),
+	}
+
+	defer func() {
+		for i := range files {
+			files[i].Close()
+		}
+	}()
+
+	second := int64(2*searchBlockSize + 2)
+	for _, f := range files {
+		if i := f.IndexString("*/"); i != searchBlockSize-2 {
+			t.Errorf("%T: expected index %d, got %d", f, searchBlockSize-2, i)
+		}
+		if i := f.IndexRune('é'); i != second-2 {
+			t.Errorf("%T: expected index %d, got %d", f, second-2, i)
+		}
+		if i := f.IndexString("x"); i != -1 {
+			t.Errorf("%T: expected index -1, got %d", f, i)
+		}
+		if f.Offset() != 0 {
+			t.Errorf("%T: expected offset = 0, got %d", f, f.Offset())
+		}
+
+		f.Next()
+		if !f.SkipUntil("*/") {
+			t.Errorf("%T: expected found", f)
+		}
+		if f.Offset() != searchBlockSize-2 {
+			t.Errorf("%T: expected offset = %d, got %d", f, searchBlockSize-2, f.Offset())
+		}
+		f.Next()
+		if !f.SkipUntil("*/") {
+			t.Errorf("%T: expected found", f)
+		}
+		if f.Offset() != second {
+			t.Errorf("%T: expected offset = %d, got %d", f, second, f.Offset())
+		}
+
+		if i := f.LastIndexString("*/"); i != searchBlockSize-2 {
+			t.Errorf("%T: expected last index %d, got %d", f, searchBlockSize-2, i)
+		}
+		if i := f.LastIndexRune('a'); i != searchBlockSize-3 {
+			t.Errorf("%T: expected last index %d, got %d", f, searchBlockSize-3, i)
+		}
+		if i := f.LastIndexRune('c'); i != -1 {
+			t.Errorf("%T: expected last index -1, got %d", f, i)
+		}
+		if f.Offset() != second {
+			t.Errorf("%T: expected offset = %d, got %d", f, second, f.Offset())
+		}
+
+		if f.SkipUntil("x") {
+			t.Errorf("%T: expected not found", f)
+		}
+		if f.Offset() != int64(len(data)) {
+			t.Errorf("%T: expected offset = %d, got %d", f, len(data), f.Offset())
+		}
+		if _, eof := f.Next(); !eof {
+			t.Errorf("%T: expected EOF", f)
+		}
+		if i := f.LastIndexString("*/"); i != second {
+			t.Errorf("%T: expected last index %d, got %d", f, second, i)
+		}
+	}
+}
+
+// TestPanicReaderAtIndexReadError tests if the IndexString method of readerAt panics if the io.ReaderAt returns
+// a error diferent from io.EOF.
+func TestPanicReaderAtIndexReadError(t *testing.T) {
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Errorf("panic expected")
+			return
+		}
+		if msg := err.(error).Error(); msg != "test" {
+			t.Errorf("expected error message %q, got %q", "test", msg)
+		}
+	}()
+
+	f := NewFileFromReader(newTestReaderAt("test", errors.New("test")), 1, 1, ".")
+	f.IndexString("x")
+}
+
+// TestPanicReaderAtLastIndexReadError tests if the LastIndexString method of readerAt panics if the io.ReaderAt
+// returns a error.
+func TestPanicReaderAtLastIndexReadError(t *testing.T) {
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Errorf("panic expected")
+			return
+		}
+		if msg := err.(error).Error(); msg != "test" {
+			t.Errorf("expected error message %q, got %q", "test", msg)
+		}
+	}()
+
+	f := NewFileFromReader(newTestReaderAt("test", nil, nil, errors.New("test")), 1, 1, ".")
+	f.Next()
+	f.Next()
+	f.LastIndexString("x")
+}
+
+// TestFork tests the Fork method of the File implementations.
+func TestFork(t *testing.T) {
+	data := "abc<<END\nxyz\nEND\n"
+	files := []File{
+		NewFile([]byte(data)),
+		NewFileFromString(data),
+		NewFileFromReader(bytes.NewBuffer([]byte(data)), 4, 1<<10, "."),
+		NewFileFromReader(newTestReadSeeker([]any{[]byte(data)}, []any{[]byte(data)}), 4, 1<<10, "."),
+		NewFileFromReader(newTestReaderAt(data), 4, 1<<10, "."),
+		NewFileFromReader(bufio.NewReader(strings.NewReader(data)), 4, 1<<10, "."),
+	}
+
+	defer func() {
+		for i := range files {
+			files[i].Close()
+		}
+	}()
+
+	for _, f := range files {
+		f.ReadBytes(8)
+		fork := f.Fork()
+		if fork.Offset() != 8 {
+			t.Errorf("%T: expected fork offset = 8, got %d", f, fork.Offset())
+		}
+		if !fork.SkipUntil("\nEND\n") || fork.Offset() != 12 {
+			t.Errorf("%T: expected fork offset = 12, got %d", f, fork.Offset())
+		}
+		if f.Offset() != 8 {
+			t.Errorf("%T: expected offset = 8, got %d", f, f.Offset())
+		}
+		if r, _ := f.Next(); r != '\n' {
+			t.Errorf("%T: expected '\\n', got %q", f, r)
+		}
+		fork.Previous()
+		if r, _ := fork.Next(); r != 'z' {
+			t.Errorf("%T: expected 'z', got %q", f, r)
+		}
+		if r, _ := f.Next(); r != 'x' {
+			t.Errorf("%T: expected 'x', got %q", f, r)
+		}
+		if err := fork.Close(); err != nil {
+			t.Errorf("%T: unexpected error %v", f, err)
+		}
+		if r, _ := f.Previous(); r != 'x' {
+			t.Errorf("%T: expected 'x', got %q", f, r)
+		}
+	}
+}
+
+// TestForkConsumed tests if the storage shared by forks only reclaims the bytes consumed by all of them.
+func TestForkConsumed(t *testing.T) {
+	f := NewFileFromReader(bufio.NewReader(strings.NewReader("abcdefghijkl")), 2, 1<<10, ".").(*reader)
+	defer f.Close()
+	fork := f.Fork()
+	f.ReadBytes(8)
+	f.Consumed(8)
+	if f.s.startOffset != 0 {
+		t.Errorf("expected startOffset = 0, got %d", f.s.startOffset)
+	}
+	fork.ReadBytes(4)
+	fork.Consumed(4)
+	if f.s.startOffset != 4 {
+		t.Errorf("expected startOffset = 4, got %d", f.s.startOffset)
+	}
+	fork.Close()
+	if f.s.startOffset != 8 {
+		t.Errorf("expected startOffset = 8, got %d", f.s.startOffset)
+	}
+	if r, _ := f.Next(); r != 'i' {
+		t.Errorf("expected 'i', got %q", r)
+	}
+	if f.s.mem == nil {
+		t.Errorf("expected that the storage is not closed")
+	}
+}
+
 // testReaderAt is a io.ReadAt for tests.
 type testReaderAt struct {
 	r *strings.Reader
@@ -986,6 +1326,9 @@ func (tra *testReaderAt) Read(p []byte) (int, error) {
 
 // ReadAt implements io.ReaderAt.
 func (tra *testReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if len(tra.readAtResult) == 0 { // keeps ReadAt safe for parallel calls, as io.ReaderAt requires
+		return tra.r.ReadAt(p, off)
+	}
 	if tra.callNumber >= len(tra.readAtResult) || tra.readAtResult[tra.callNumber] == nil {
 		tra.callNumber++
 		return tra.r.ReadAt(p, off)
@@ -1097,6 +1440,8 @@ func (trs *testReadSeeker) Seek(offset int64, whence int) (int64, error) {
 			curOff += int64(len(b))
 		}
 		if curOff == offset {
+			trs.pos = len(trs.data)
+			trs.offset = 0
 			return offset, nil
 		}
 		return offset, io.EOF
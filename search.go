@@ -0,0 +1,181 @@
+package rem
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// searchBlockSize is the number of bytes read at a time by the searches on inputs that can not be accessed
+// directly in memory.
+const searchBlockSize = 4096
+
+// indexAt returns the offset of the first instance of sep in ra at or after offset, or -1 if sep is not present. If
+// sep is not present, end is the offset of the EOF. It panics on error.
+func indexAt(ra io.ReaderAt, offset int64, sep []byte) (i, end int64) {
+	buf := make([]byte, 0, searchBlockSize+len(sep))
+	// pos is the offset of buf[0]
+	pos := offset
+	for {
+		m := len(buf)
+		buf = buf[:cap(buf)]
+		n, err := ra.ReadAt(buf[m:], pos+int64(m))
+		buf = buf[:m+n]
+		if i := bytes.Index(buf, sep); i >= 0 {
+			return pos + int64(i), -1
+		}
+		if err == io.EOF {
+			return -1, pos + int64(len(buf))
+		} else if err != nil {
+			panic(err)
+		}
+
+		// keeps the bytes that can be the start of a sep that crosses the block boundary
+		keep := min(len(sep)-1, len(buf))
+		pos += int64(len(buf) - keep)
+		copy(buf, buf[len(buf)-keep:])
+		buf = buf[:keep]
+	}
+}
+
+// lastIndexAt returns the offset of the last instance of sep in ra that starts at or after start and ends at or
+// before end, or -1 if sep is not present. It panics on error.
+func lastIndexAt(ra io.ReaderAt, start, end int64, sep []byte) int64 {
+	if start == end {
+		if len(sep) == 0 {
+			return end
+		}
+		return -1
+	}
+	var carry []byte
+	for end > start {
+		size := min(int64(searchBlockSize), end-start)
+		block := make([]byte, size, size+int64(len(carry)))
+		n, err := ra.ReadAt(block, end-size)
+		if n < len(block) {
+			panic(err)
+		}
+		block = append(block, carry...)
+		if i := bytes.LastIndex(block, sep); i >= 0 {
+			return end - size + int64(i)
+		}
+		end -= size
+
+		// keeps the bytes that can be the end of a sep that crosses the block boundary
+		carry = bytes.Clone(block[:min(len(sep)-1, len(block))])
+	}
+	return -1
+}
+
+// seekerAt adapts a io.ReadSeeker to a io.ReaderAt. Note that ReadAt changes the seek position of the input, unless
+// mu is not nil.
+type seekerAt struct {
+	rs io.ReadSeeker
+	// mu, if not nil, is held by ReadAt, that restores the seek position of rs after reading.
+	mu *sync.Mutex
+}
+
+// ReadAt implements io.ReaderAt.
+func (sa seekerAt) ReadAt(p []byte, off int64) (n int, err error) {
+	if sa.mu != nil {
+		sa.mu.Lock()
+		defer sa.mu.Unlock()
+		var pos int64
+		if pos, err = sa.rs.Seek(0, io.SeekCurrent); err != nil {
+			return
+		}
+		defer func() {
+			if _, serr := sa.rs.Seek(pos, io.SeekStart); serr != nil && err == nil {
+				err = serr
+			}
+		}()
+	}
+	if _, err = sa.rs.Seek(off, io.SeekStart); err != nil {
+		return
+	}
+	n, err = io.ReadFull(sa.rs, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return
+}
+
+// indexFile returns the offset of the first instance of sep at or after the current offset of f, or -1 if sep is not
+// present. It reads a fork of f, so it is used by the Files that do not have random access to their input.
+func indexFile(f File, sep []byte) int64 {
+	fork := f.Fork()
+	defer fork.Close()
+	if len(sep) == 0 {
+		return fork.Offset()
+	}
+	// offsets are the offsets of the bytes of window, they are not contiguous in the Files that skip bytes of their
+	// input.
+	window := make([]byte, 0, 2*len(sep))
+	offsets := make([]int64, 0, 2*len(sep))
+	for {
+		offset := fork.Offset()
+		b, eof := fork.NextByte()
+		if eof {
+			return -1
+		}
+		if len(window) == cap(window) {
+			window = append(window[:0], window[len(window)-len(sep)+1:]...)
+			offsets = append(offsets[:0], offsets[len(offsets)-len(sep)+1:]...)
+		}
+		window = append(window, b)
+		offsets = append(offsets, offset)
+		if bytes.HasSuffix(window, sep) {
+			return offsets[len(offsets)-len(sep)]
+		}
+	}
+}
+
+// lastIndexFile returns the offset of the last instance of sep that ends at or before the current offset of f, or -1
+// if sep is not present. It reads a fork of f backwards.
+func lastIndexFile(f File, sep []byte) int64 {
+	fork := f.Fork()
+	defer fork.Close()
+	if len(sep) == 0 {
+		return fork.Offset()
+	}
+	window := make([]byte, 2*len(sep))
+	start := len(window)
+	for {
+		b, onStart := fork.PreviousByte()
+		if onStart {
+			return -1
+		}
+		if start == 0 {
+			copy(window[len(window)-len(sep)+1:], window[:len(sep)-1])
+			start = len(window) - len(sep) + 1
+		}
+		start--
+		window[start] = b
+		if bytes.HasPrefix(window[start:], sep) {
+			return fork.Offset()
+		}
+	}
+}
+
+// skipFile put the offset of f at the start of the first instance of delim at or after the current offset, or at the
+// EOF if delim is not present.
+func skipFile(f File, delim []byte) (found bool) {
+	i := indexFile(f, delim)
+	if i < 0 {
+		for _, eof := f.ReadBytes(searchBlockSize); !eof; _, eof = f.ReadBytes(searchBlockSize) {
+		}
+		return false
+	}
+	seekFile(f, i)
+	return true
+}
+
+// seekFile put the offset of f at offset, moving backwards with PreviousByte and forwards with ReadBytes.
+func seekFile(f File, offset int64) {
+	for cur := f.Offset(); cur > offset; cur-- {
+		f.PreviousByte()
+	}
+	if cur := f.Offset(); cur < offset {
+		f.ReadBytes(int(offset - cur))
+	}
+}
@@ -0,0 +1,75 @@
+package rem
+
+// NewSplicedFile returns a File that reads f from its current offset, skipping the line continuations: a backslash
+// followed by "\n" or "\r\n". The offsets are the offsets of f, and the offset before a rune is always the offset of
+// the rune in f, so the line continuations are skipped as soon as they are reached. Close closes f.
+func NewSplicedFile(f File) File {
+	for skipContinuation(f) {
+	}
+	return newView(f, spliceCodec{}, true)
+}
+
+// spliceCodec is the codec of the Files returned by NewSplicedFile. A unit is a rune with the line continuations
+// after it, so the offset before a rune is the offset of the rune.
+type spliceCodec struct{}
+
+// next decodes the unit at the current offset of src.
+func (spliceCodec) next(src File, fresh bool) (r rune, eof bool) {
+	if r, eof = src.Next(); !eof {
+		for skipContinuation(src) {
+		}
+	}
+	return r, eof
+}
+
+// skipContinuation skips the line continuation at the current offset of src, if there is one.
+func skipContinuation(src File) bool {
+	start := src.Offset()
+	if b, eof := src.NextByte(); eof || b != '\\' {
+		moveFile(src, start)
+		return false
+	}
+	b, eof := src.NextByte()
+	if !eof && b == '\r' {
+		b, eof = src.NextByte()
+	}
+	if eof || b != '\n' {
+		moveFile(src, start)
+		return false
+	}
+	return true
+}
+
+// previous decodes the unit before the current offset of src.
+func (spliceCodec) previous(src File) (r rune, onStart bool) {
+	end := src.Offset()
+	for skipContinuationBefore(src) {
+	}
+	if r, onStart = src.Previous(); onStart {
+		moveFile(src, end)
+	}
+	return r, onStart
+}
+
+// skipContinuationBefore skips backwards the line continuation before the current offset of src, if there is one.
+func skipContinuationBefore(src File) bool {
+	end := src.Offset()
+	if b, onStart := src.PreviousByte(); onStart || b != '\n' {
+		moveFile(src, end)
+		return false
+	}
+	b, onStart := src.PreviousByte()
+	if !onStart && b == '\r' {
+		b, onStart = src.PreviousByte()
+	}
+	if onStart || b != '\\' {
+		moveFile(src, end)
+		return false
+	}
+	return true
+}
+
+// clone returns the codec, because it has no state.
+func (sc spliceCodec) clone() codec {
+	return sc
+}
@@ -0,0 +1,89 @@
+package rem
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestSplicedFile tests the skipping of the line continuations in both directions and the offsets.
+func TestSplicedFile(t *testing.T) {
+	const data = "\\\na\\\nç\\\r\n\\\nb\\\\\nc\\x\\\n"
+	sf := NewSplicedFile(NewFileFromString(data))
+	defer sf.Close()
+
+	want := []rune("açb\\c\\x")
+	offsets := []int64{5, 12, 13, 16, 17, 18, 21}
+	for i, w := range want {
+		if r, eof := sf.Next(); eof || r != w {
+			t.Fatalf("expected %q at %d, got %q", w, i, r)
+		}
+		if offset := sf.Offset(); offset != offsets[i] {
+			t.Errorf("expected the offset %d after %q, got %d", offsets[i], w, offset)
+		}
+	}
+	if _, eof := sf.Next(); !eof {
+		t.Errorf("expected EOF")
+	}
+	if offset := sf.Offset(); offset != 21 {
+		t.Errorf("unexpected offset %d", offset)
+	}
+
+	for i := len(want) - 1; i >= 0; i-- {
+		if r, onStart := sf.Previous(); onStart || r != want[i] {
+			t.Fatalf("expected %q at %d, got %q", want[i], i, r)
+		}
+		if i > 0 && sf.Offset() != offsets[i-1] {
+			t.Errorf("expected the offset %d before %q, got %d", offsets[i-1], want[i], sf.Offset())
+		}
+	}
+	if _, onStart := sf.Previous(); !onStart || sf.Offset() != 2 {
+		t.Errorf("expected the start, got %d", sf.Offset())
+	}
+
+	if i := sf.IndexString("aç"); i != 2 {
+		t.Errorf("unexpected index %d", i)
+	}
+	if i := sf.IndexString("çb"); i != 5 {
+		t.Errorf("unexpected index %d", i)
+	}
+	if !sf.SkipUntil("b\\") || sf.Offset() != 12 {
+		t.Errorf("unexpected offset %d", sf.Offset())
+	}
+	sf.ReadBytes(3)
+	if i := sf.LastIndexRune('ç'); i != 5 {
+		t.Errorf("unexpected index %d", i)
+	}
+	if b, _ := sf.PreviousByte(); b != 'c' {
+		t.Errorf("expected 'c', got %q", b)
+	}
+	tx := sf.Begin()
+	sf.PreviousByte()
+	sf.PreviousByte()
+	if b, _ := sf.PreviousByte(); b != 0xa7 {
+		t.Errorf("expected 0xa7, got %#x", b)
+	}
+	if offset := sf.Offset(); offset != 6 {
+		t.Errorf("unexpected offset %d", offset)
+	}
+	tx.Rollback()
+	if offset := sf.Offset(); offset != 16 {
+		t.Errorf("unexpected offset %d", offset)
+	}
+}
+
+// TestSplicedFileStream tests the consumption and the forks on a stream.
+func TestSplicedFileStream(t *testing.T) {
+	sf := NewSplicedFile(NewFileFromReader(strings.NewReader("ab\\\ncd\\\r\nef"), 4, 0, ""))
+	defer sf.Close()
+	sf.ReadBytes(3)
+	fork := sf.Fork()
+	defer fork.Close()
+	sf.Consumed(5)
+	if got := readString(sf); got != "def" {
+		t.Errorf("unexpected content %q", got)
+	}
+	sf.Consumed(11)
+	if got := readString(fork); got != "def" {
+		t.Errorf("unexpected content %q", got)
+	}
+}
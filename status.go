@@ -0,0 +1,38 @@
+package rem
+
+import "strconv"
+
+// Status is the status of a read from a File whose input can grow, like a PushFile or a FollowFile.
+type Status int
+
+const (
+	// OK indicates that a rune was read.
+	OK Status = iota
+	// EOF indicates that the input is at the end, and it will not grow.
+	EOF
+	// NeedMoreInput indicates that the bytes available so far were all read, or that they end with an incomplete rune,
+	// but the input can grow. The client can suspend the parse and resume it when there is more input.
+	NeedMoreInput
+	// Truncated indicates that a followed file was truncated. The offset was put at the start of the file.
+	Truncated
+	// Rotated indicates that a followed file was replaced by a new file with the same name, like in log rotation.
+	// The old file was read until its end, and the offset was put at the start of the new file.
+	Rotated
+)
+
+// String returns the name of the status.
+func (s Status) String() string {
+	switch s {
+	case OK:
+		return "OK"
+	case EOF:
+		return "EOF"
+	case NeedMoreInput:
+		return "NeedMoreInput"
+	case Truncated:
+		return "Truncated"
+	case Rotated:
+		return "Rotated"
+	}
+	return "Status(" + strconv.Itoa(int(s)) + ")"
+}
@@ -0,0 +1,13 @@
+package rem
+
+import "testing"
+
+// TestStatusString tests the String method of Status.
+func TestStatusString(t *testing.T) {
+	for s, es := range map[Status]string{OK: "OK", EOF: "EOF", NeedMoreInput: "NeedMoreInput", Truncated: "Truncated",
+		Rotated: "Rotated", 7: "Status(7)"} {
+		if s.String() != es {
+			t.Errorf("expected %q, got %q", es, s.String())
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package rem
+
+import "errors"
+
+// SubFile is a File that reads only a range of bytes of another File, the parent. The offsets start at 0 at the start
+// of the range.
+type SubFile interface {
+	File
+
+	// ParentOffset returns the offset in the parent that corresponds to offset.
+	ParentOffset(offset int64) int64
+}
+
+// subFile is a SubFile.
+type subFile struct {
+	// f is a fork of the parent.
+	f File
+	// start is the offset of the start of the range in the parent.
+	start int64
+	// end is the offset of the end of the range in the parent.
+	end int64
+	// tx are the open transactions.
+	tx transactions
+}
+
+// NewSubFile returns a SubFile that reads the bytes of parent in [start, end). The SubFile reads a fork of parent, so
+// the offset of parent remains unchanged, and start must not be before the bytes consumed in parent. The runes split
+// by the bounds of the range are not read.
+func NewSubFile(parent File, start, end int64) (SubFile, error) {
+	if start < 0 || end < start {
+		return nil, errors.New("invalid range")
+	}
+	f := parent.Fork()
+	moveFile(f, start)
+	if f.Offset() != start {
+		f.Close()
+		return nil, errors.New("range start after the EOF")
+	}
+	return &subFile{f: f, start: start, end: end}, nil
+}
+
+// moveFile put the offset of f at offset. It moves backwards with setOffset if f implements it.
+func moveFile(f File, offset int64) {
+	if tf, ok := f.(txFile); ok && offset <= f.Offset() {
+		tf.setOffset(offset)
+		return
+	}
+	seekFile(f, offset)
+}
+
+// ParentOffset returns the offset in the parent that corresponds to offset.
+func (sf *subFile) ParentOffset(offset int64) int64 {
+	return sf.start + offset
+}
+
+// Next returns the rune at the current offset, or eof = true at the end of the range.
+func (sf *subFile) Next() (r rune, eof bool) {
+	if sf.f.Offset() >= sf.end {
+		return 0, true
+	}
+	if r, eof = sf.f.Next(); !eof && sf.f.Offset() > sf.end {
+		sf.f.Previous()
+		return 0, true
+	}
+	return r, eof
+}
+
+// Previous returns the rune before the current offset, or onStart = true at the start of the range.
+func (sf *subFile) Previous() (r rune, onStart bool) {
+	if sf.f.Offset() <= sf.start {
+		return 0, true
+	}
+	if r, onStart = sf.f.Previous(); !onStart && sf.f.Offset() < sf.start {
+		sf.f.Next()
+		return 0, true
+	}
+	return r, onStart
+}
+
+// NextByte returns the byte at the current offset, or eof = true at the end of the range.
+func (sf *subFile) NextByte() (b byte, eof bool) {
+	if sf.f.Offset() >= sf.end {
+		return 0, true
+	}
+	return sf.f.NextByte()
+}
+
+// PreviousByte returns the byte before the current offset, or onStart = true at the start of the range.
+func (sf *subFile) PreviousByte() (b byte, onStart bool) {
+	if sf.f.Offset() <= sf.start {
+		return 0, true
+	}
+	return sf.f.PreviousByte()
+}
+
+// ReadBytes returns the next n bytes, up to the end of the range.
+func (sf *subFile) ReadBytes(n int) (p []byte, eof bool) {
+	m := int(min(int64(n), sf.end-sf.f.Offset()))
+	p, eof = sf.f.ReadBytes(m)
+	return p, eof || len(p) < n
+}
+
+// IndexRune returns the offset of the first instance of r at or after the current offset, or -1 if r is not present
+// in the range.
+func (sf *subFile) IndexRune(r rune) int64 {
+	return sf.IndexString(string(r))
+}
+
+// IndexString returns the offset of the first instance of s at or after the current offset, or -1 if s is not
+// present in the range. It does not read the parent after the end of the range.
+func (sf *subFile) IndexString(s string) int64 {
+	return indexFile(sf, []byte(s))
+}
+
+// LastIndexRune returns the offset of the last instance of r that ends at or before the current offset, or -1 if r is
+// not present in the range.
+func (sf *subFile) LastIndexRune(r rune) int64 {
+	return sf.LastIndexString(string(r))
+}
+
+// LastIndexString returns the offset of the last instance of s that ends at or before the current offset, or -1 if s
+// is not present in the range.
+func (sf *subFile) LastIndexString(s string) int64 {
+	i := sf.f.LastIndexString(s)
+	if i < sf.start {
+		return -1
+	}
+	return i - sf.start
+}
+
+// SkipUntil put the offset at the start of the first instance of delim at or after the current offset. If delim is
+// not present in the range, it put the offset at the end of the range.
+func (sf *subFile) SkipUntil(delim string) (found bool) {
+	i := sf.IndexString(delim)
+	if i < 0 {
+		sf.f.ReadBytes(int(sf.end - sf.f.Offset()))
+		return false
+	}
+	sf.f.ReadBytes(int(i - sf.Offset()))
+	return true
+}
+
+// Consumed marks the bytes before offset as consumed.
+func (sf *subFile) Consumed(offset int64) {
+	if offset > sf.Offset() {
+		panic(errors.New("invalid offset"))
+	}
+	if !sf.tx.deferConsumed(offset) {
+		sf.consume(offset)
+	}
+}
+
+// consume marks the bytes before offset as consumed in the fork of the parent.
+func (sf *subFile) consume(offset int64) {
+	sf.f.Consumed(sf.start + offset)
+}
+
+// Begin begins a transaction at the current offset. Transactions can be nested.
+func (sf *subFile) Begin() Tx {
+	return sf.tx.begin(sf)
+}
+
+// setOffset put the offset at offset.
+func (sf *subFile) setOffset(offset int64) {
+	moveFile(sf.f, sf.start+offset)
+}
+
+// Fork returns a new SubFile over the same range with an independent offset initially equal to the current offset of
+// sf.
+func (sf *subFile) Fork() File {
+	return &subFile{f: sf.f.Fork(), start: sf.start, end: sf.end}
+}
+
+// Offset returns the current offset.
+func (sf *subFile) Offset() int64 {
+	return sf.f.Offset() - sf.start
+}
+
+// Close closes the fork of the parent.
+func (sf *subFile) Close() error {
+	return sf.f.Close()
+}
@@ -0,0 +1,143 @@
+package rem
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestSubFile tests the bounds of the range on the backends.
+func TestSubFile(t *testing.T) {
+	const data = "<p>çx=1;</p>"
+	parents := []File{
+		NewFile([]byte(data)),
+		NewFileFromString(data),
+		newReaderAt(strings.NewReader(data)),
+		NewFileFromReader(strings.NewReader(data), 32, 0, ""),
+	}
+	for _, parent := range parents {
+		parent.ReadBytes(9)
+		sf, err := NewSubFile(parent, 3, 9)
+		if err != nil {
+			t.Fatalf("%T: unexpected error %v", parent, err)
+		}
+		if offset := parent.Offset(); offset != 9 {
+			t.Errorf("%T: unexpected offset of the parent %d", parent, offset)
+		}
+		if got := readString(sf); got != "çx=1;" {
+			t.Errorf("%T: unexpected content %q", parent, got)
+		}
+		if offset := sf.Offset(); offset != 6 || sf.ParentOffset(offset) != 9 {
+			t.Errorf("%T: unexpected offset %d", parent, offset)
+		}
+		if b, eof := sf.NextByte(); !eof {
+			t.Errorf("%T: expected EOF, got %q", parent, b)
+		}
+		if i := sf.LastIndexRune('ç'); i != 0 {
+			t.Errorf("%T: unexpected index %d", parent, i)
+		}
+		if i := sf.LastIndexString("<p>"); i != -1 {
+			t.Errorf("%T: unexpected index %d", parent, i)
+		}
+
+		tx := sf.Begin()
+		for i := 0; i < 5; i++ {
+			sf.Previous()
+		}
+		if _, onStart := sf.Previous(); !onStart {
+			t.Errorf("%T: expected the start", parent)
+		}
+		if b, onStart := sf.PreviousByte(); !onStart {
+			t.Errorf("%T: expected the start, got %q", parent, b)
+		}
+		if i := sf.IndexString("</p>"); i != -1 {
+			t.Errorf("%T: unexpected index %d", parent, i)
+		}
+		if i := sf.IndexRune('='); i != 3 {
+			t.Errorf("%T: unexpected index %d", parent, i)
+		}
+		if sf.SkipUntil(">") || sf.Offset() != 6 {
+			t.Errorf("%T: expected the end of the range, got %d", parent, sf.Offset())
+		}
+		tx.Rollback()
+		if offset := sf.Offset(); offset != 6 {
+			t.Errorf("%T: unexpected offset %d", parent, offset)
+		}
+
+		fork := sf.Fork()
+		moveFile(fork, 1)
+		if p, eof := fork.ReadBytes(10); !eof || !bytes.Equal(p, []byte("\xa7x=1;")) {
+			t.Errorf("%T: unexpected result %q, %v", parent, p, eof)
+		}
+		fork.Close()
+		sf.Consumed(2)
+		sf.Close()
+		parent.Close()
+	}
+}
+
+// TestSubFileSplitRune tests a range that splits runes, and the invalid ranges.
+func TestSubFileSplitRune(t *testing.T) {
+	parent := NewFileFromString("aççb")
+	sf, err := NewSubFile(parent, 1, 4)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if r, _ := sf.Next(); r != 'ç' {
+		t.Errorf("expected 'ç', got %q", r)
+	}
+	if r, eof := sf.Next(); !eof {
+		t.Errorf("expected EOF, got %q", r)
+	}
+
+	sf, err = NewSubFile(parent, 2, 5)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	moveFile(sf, 3)
+	if r, _ := sf.Previous(); r != 'ç' {
+		t.Errorf("expected 'ç', got %q", r)
+	}
+	if r, onStart := sf.Previous(); !onStart {
+		t.Errorf("expected the start, got %q", r)
+	}
+	if offset := sf.Offset(); offset != 1 {
+		t.Errorf("unexpected offset %d", offset)
+	}
+
+	if _, err := NewSubFile(parent, 3, 2); err == nil {
+		t.Errorf("error expected")
+	}
+	if _, err := NewSubFile(parent, 7, 8); err == nil {
+		t.Errorf("error expected")
+	}
+}
+
+// TestSubFileStream tests if the searches of a range of a streaming parent do not read the parent after the end of
+// the range.
+func TestSubFileStream(t *testing.T) {
+	data := "abcdefgh" + strings.Repeat("x", 1<<10)
+	parent := NewFileFromReader(bufio.NewReader(strings.NewReader(data)), 16, 64, t.TempDir())
+	defer parent.Close()
+	sf, err := NewSubFile(parent, 0, 8)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	defer sf.Close()
+	if i := sf.IndexString("gh"); i != 6 {
+		t.Errorf("expected 6, got %d", i)
+	}
+	if i := sf.IndexString("zz"); i != -1 {
+		t.Errorf("expected -1, got %d", i)
+	}
+	if i := sf.IndexString("hx"); i != -1 {
+		t.Errorf("expected -1, got %d", i)
+	}
+	if sf.SkipUntil("zz") {
+		t.Errorf("expected that the delimiter was not found")
+	}
+	if offset := sf.Offset(); offset != 8 {
+		t.Errorf("unexpected offset %d", offset)
+	}
+}
@@ -0,0 +1,151 @@
+package rem
+
+import "sync"
+
+// synchronized is a File that guards another File with a mutex.
+type synchronized struct {
+	mu sync.Mutex
+	// f is the guarded File.
+	f File
+}
+
+// Synchronized returns a File that is safe for concurrent use by multiple goroutines. Each method call on the returned
+// File is atomic. Note that a sequence of calls is not atomic, so the clients that share the File must agree on who
+// moves the offset, or use Fork to have independent cursors. The File returned by Fork and the Tx returned by Begin are
+// synchronized too.
+func Synchronized(f File) File {
+	if s, ok := f.(*synchronized); ok {
+		return s
+	}
+	return &synchronized{f: f}
+}
+
+// Next calls the Next method of the guarded File.
+func (s *synchronized) Next() (r rune, eof bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.Next()
+}
+
+// Previous calls the Previous method of the guarded File.
+func (s *synchronized) Previous() (r rune, onStart bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.Previous()
+}
+
+// Consumed calls the Consumed method of the guarded File.
+func (s *synchronized) Consumed(offset int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.f.Consumed(offset)
+}
+
+// Offset calls the Offset method of the guarded File.
+func (s *synchronized) Offset() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.Offset()
+}
+
+// NextByte calls the NextByte method of the guarded File.
+func (s *synchronized) NextByte() (b byte, eof bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.NextByte()
+}
+
+// PreviousByte calls the PreviousByte method of the guarded File.
+func (s *synchronized) PreviousByte() (b byte, onStart bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.PreviousByte()
+}
+
+// ReadBytes calls the ReadBytes method of the guarded File.
+func (s *synchronized) ReadBytes(n int) (p []byte, eof bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.ReadBytes(n)
+}
+
+// IndexRune calls the IndexRune method of the guarded File.
+func (s *synchronized) IndexRune(r rune) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.IndexRune(r)
+}
+
+// IndexString calls the IndexString method of the guarded File.
+func (s *synchronized) IndexString(str string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.IndexString(str)
+}
+
+// LastIndexRune calls the LastIndexRune method of the guarded File.
+func (s *synchronized) LastIndexRune(r rune) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.LastIndexRune(r)
+}
+
+// LastIndexString calls the LastIndexString method of the guarded File.
+func (s *synchronized) LastIndexString(str string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.LastIndexString(str)
+}
+
+// SkipUntil calls the SkipUntil method of the guarded File.
+func (s *synchronized) SkipUntil(delim string) (found bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.SkipUntil(delim)
+}
+
+// Begin calls the Begin method of the guarded File and returns a synchronized Tx.
+func (s *synchronized) Begin() Tx {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &synchronizedTx{s: s, tx: s.f.Begin()}
+}
+
+// Fork calls the Fork method of the guarded File and returns the new File synchronized.
+func (s *synchronized) Fork() File {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Synchronized(s.f.Fork())
+}
+
+// Close calls the Close method of the guarded File.
+func (s *synchronized) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.Close()
+}
+
+// synchronizedTx is a Tx guarded by the mutex of a synchronized File.
+type synchronizedTx struct {
+	s  *synchronized
+	tx Tx
+}
+
+// Commit calls the Commit method of the guarded Tx.
+func (st *synchronizedTx) Commit() {
+	st.s.mu.Lock()
+	defer st.s.mu.Unlock()
+	st.tx.Commit()
+}
+
+// Rollback calls the Rollback method of the guarded Tx.
+func (st *synchronizedTx) Rollback() {
+	st.s.mu.Lock()
+	defer st.s.mu.Unlock()
+	st.tx.Rollback()
+}
+
+// Offset calls the Offset method of the guarded Tx.
+func (st *synchronizedTx) Offset() int64 {
+	return st.tx.Offset()
+}
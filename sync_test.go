@@ -0,0 +1,115 @@
+package rem
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// raceData is the input of the concurrency tests.
+var raceData = strings.Repeat("abcdefgh", 1<<9)
+
+// raceFiles returns a File of each backend that reads raceData.
+func raceFiles(t *testing.T) []File {
+	dir := t.TempDir()
+	return []File{
+		NewFile([]byte(raceData)),
+		NewFileFromString(raceData),
+		NewFileFromReader(bytes.NewBuffer([]byte(raceData)), 1<<6, 1<<14, dir),
+		NewFileFromReader(newTestReadSeeker([]any{[]byte(raceData)}, []any{[]byte(raceData)}), 1<<6, 1<<14, dir),
+		NewFileFromReader(newTestReaderAt(raceData), 1<<6, 1<<14, dir),
+		NewFileFromReader(bufio.NewReader(strings.NewReader(raceData)), 1<<6, 1<<14, dir),
+	}
+}
+
+// readAll reads f until EOF checking the runes, and then goes back to the start using Previous.
+func readAll(t *testing.T, f File) {
+	for i := 0; ; i++ {
+		r, eof := f.Next()
+		if eof {
+			if i != len(raceData) {
+				t.Errorf("%T: unexpected EOF at %d", f, i)
+			}
+			break
+		}
+		if r != rune(raceData[i]) {
+			t.Errorf("%T: expected %q at %d, got %q", f, raceData[i], i, r)
+			return
+		}
+		if i%64 == 0 {
+			f.IndexString("h")
+		}
+	}
+	for i := len(raceData) - 1; i >= 0; i-- {
+		if r, _ := f.Previous(); r != rune(raceData[i]) {
+			t.Errorf("%T: expected %q at %d, got %q", f, raceData[i], i, r)
+			return
+		}
+	}
+}
+
+// TestForksConcurrently tests if the forks of every backend can be used from different goroutines. It is meant to
+// be run with the race detector.
+func TestForksConcurrently(t *testing.T) {
+	for _, f := range raceFiles(t) {
+		forks := []File{f, f.Fork(), f.Fork(), f.Fork()}
+		var wg sync.WaitGroup
+		for _, fork := range forks {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				readAll(t, fork)
+			}()
+		}
+		wg.Wait()
+		for _, fork := range forks {
+			fork.Close()
+		}
+	}
+}
+
+// TestSynchronized tests if a synchronized File of every backend can be used from different goroutines. It is meant
+// to be run with the race detector.
+func TestSynchronized(t *testing.T) {
+	for _, f := range raceFiles(t) {
+		s := Synchronized(f)
+		if Synchronized(s) != s {
+			t.Errorf("%T: expected that Synchronized does not wrap a synchronized File", f)
+		}
+
+		tx := s.Begin()
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		read := make([]byte, 0, len(raceData))
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					// the offset and the byte must be read atomically
+					mu.Lock()
+					b, eof := s.NextByte()
+					if eof {
+						mu.Unlock()
+						return
+					}
+					read = append(read, b)
+					mu.Unlock()
+					s.Offset()
+				}
+			}()
+		}
+		wg.Wait()
+		if string(read) != raceData {
+			t.Errorf("%T: the bytes read differ from the input", f)
+		}
+
+		tx.Rollback()
+		fork := s.Fork()
+		readAll(t, fork)
+		fork.Close()
+		s.Close()
+	}
+}
@@ -0,0 +1,117 @@
+package rem
+
+import "errors"
+
+// Tx is a transaction of a File. It allows speculative parsing: the client begins a transaction, tries to parse, and
+// then commits the transaction keeping the offset, or rolls it back restoring the offset of the File.
+//
+// The calls to Consumed while a transaction is open are deferred until the outermost transaction is committed,
+// so rolling back is always safe. A transaction that is rolled back discards the Consumed calls made inside it.
+type Tx interface {
+	// Commit closes the transaction keeping the current offset. The open transactions begun after this one are committed too.
+	// It panics if the transaction is closed.
+	Commit()
+
+	// Rollback closes the transaction and restores the offset of the File to the offset at the start of the transaction.
+	// The open transactions begun after this one are rolled back too. It panics if the transaction is closed.
+	Rollback()
+
+	// Offset returns the offset of the File at the start of the transaction.
+	Offset() int64
+}
+
+// txFile is a File whose offset can be set directly. It is used by the transactions.
+type txFile interface {
+	// Offset returns the current offset.
+	Offset() int64
+	// setOffset put the offset at offset.
+	setOffset(offset int64)
+	// consume marks the bytes before offset as consumed, like Consumed does when there is no open transaction.
+	consume(offset int64)
+}
+
+// SetOffset puts the offset of f at offset, which must be the offset of a rune that f already read and did not mark as
+// consumed. It is useful to restore a saved offset, like a backtracking parser does. If the backend of f supports it,
+// the offset is set directly, otherwise f moves byte by byte.
+func SetOffset(f File, offset int64) {
+	if tf, ok := f.(txFile); ok {
+		tf.setOffset(offset)
+		return
+	}
+	seekFile(f, offset)
+}
+
+// transactions holds the open transactions of a File.
+type transactions struct {
+	// open are the open transactions, from the outermost to the innermost.
+	open []*tx
+}
+
+// begin begins a new transaction on f.
+func (ts *transactions) begin(f txFile) Tx {
+	t := &tx{ts: ts, f: f, offset: f.Offset(), consumed: -1}
+	ts.open = append(ts.open, t)
+	return t
+}
+
+// deferConsumed defers the Consumed call with offset to the innermost open transaction. It returns false if there
+// is no open transaction.
+func (ts *transactions) deferConsumed(offset int64) bool {
+	if len(ts.open) == 0 {
+		return false
+	}
+	t := ts.open[len(ts.open)-1]
+	t.consumed = max(t.consumed, offset)
+	return true
+}
+
+// tx implements Tx.
+type tx struct {
+	// ts are the transactions of the File.
+	ts *transactions
+	// f is the File.
+	f txFile
+	// offset is the offset at the start of the transaction.
+	offset int64
+	// consumed is the greatest offset passed to Consumed while the transaction is open, or -1.
+	consumed int64
+}
+
+// Commit closes the transaction keeping the current offset. The open transactions begun after t are committed too.
+// It panics if t is closed.
+func (t *tx) Commit() {
+	i := t.index()
+	consumed := int64(-1)
+	for _, inner := range t.ts.open[i:] {
+		consumed = max(consumed, inner.consumed)
+	}
+	t.ts.open = t.ts.open[:i]
+	if i > 0 {
+		parent := t.ts.open[i-1]
+		parent.consumed = max(parent.consumed, consumed)
+	} else if consumed >= 0 {
+		t.f.consume(consumed)
+	}
+}
+
+// Rollback closes the transaction and restores the offset of the File to the offset at the start of t. The open
+// transactions begun after t are rolled back too. It panics if t is closed.
+func (t *tx) Rollback() {
+	t.ts.open = t.ts.open[:t.index()]
+	t.f.setOffset(t.offset)
+}
+
+// Offset returns the offset of the File at the start of t.
+func (t *tx) Offset() int64 {
+	return t.offset
+}
+
+// index returns the index of t in the open transactions. It panics if t is closed.
+func (t *tx) index() int {
+	for i, open := range t.ts.open {
+		if open == t {
+			return i
+		}
+	}
+	panic(errors.New("transaction is closed"))
+}
@@ -0,0 +1,150 @@
+package rem
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestTx tests the transactions of the File implementations.
+func TestTx(t *testing.T) {
+	data := "(a)b+c"
+	files := []File{
+		NewFile([]byte(data)),
+		NewFileFromString(data),
+		NewFileFromReader(bytes.NewBuffer([]byte(data)), 2, 1<<10, t.TempDir()),
+		NewFileFromReader(strings.NewReader(data), 2, 1<<10, t.TempDir()),
+		NewFileFromReader(newTestReaderAt(data), 2, 1<<10, t.TempDir()),
+		NewFileFromReader(bufio.NewReader(strings.NewReader(data)), 2, 1<<10, t.TempDir()),
+	}
+
+	defer func() {
+		for i := range files {
+			files[i].Close()
+		}
+	}()
+
+	for _, f := range files {
+		outer := f.Begin()
+		f.Next()
+		inner := f.Begin()
+		f.Next()
+		f.Next()
+		if inner.Offset() != 1 {
+			t.Errorf("%T: expected inner.Offset() = 1, got %d", f, inner.Offset())
+		}
+		inner.Rollback()
+		if f.Offset() != 1 {
+			t.Errorf("%T: expected offset = 1, got %d", f, f.Offset())
+		}
+
+		inner = f.Begin()
+		f.SkipUntil("+")
+		inner.Commit()
+		outer.Rollback()
+		if f.Offset() != 0 {
+			t.Errorf("%T: expected offset = 0, got %d", f, f.Offset())
+		}
+
+		outer = f.Begin()
+		f.SkipUntil("+")
+		f.Begin()
+		f.Next()
+		outer.Commit()
+		if r, _ := f.Next(); r != 'c' {
+			t.Errorf("%T: expected 'c', got %q", f, r)
+		}
+	}
+}
+
+// TestSetOffset tests if SetOffset restores offsets that were already read, backwards and forwards.
+func TestSetOffset(t *testing.T) {
+	data := "0123456789çã"
+	files := []File{
+		NewFile([]byte(data)),
+		NewFileFromReader(bytes.NewBuffer([]byte(data)), 2, 1<<10, t.TempDir()),
+		NewFileFromReader(strings.NewReader(data), 2, 1<<10, t.TempDir()),
+		NewFileFromReader(bufio.NewReader(strings.NewReader(data)), 2, 1<<10, t.TempDir()),
+		Synchronized(NewFileFromString(data)),
+	}
+
+	defer func() {
+		for i := range files {
+			files[i].Close()
+		}
+	}()
+
+	for _, f := range files {
+		f.SkipUntil("ã")
+		f.Consumed(2)
+		SetOffset(f, 3)
+		if r, _ := f.Next(); r != '3' {
+			t.Errorf("%T: expected '3', got %q", f, r)
+		}
+		SetOffset(f, 10)
+		if r, _ := f.Next(); r != 'ç' {
+			t.Errorf("%T: expected 'ç', got %q", f, r)
+		}
+	}
+}
+
+// TestTxDefersConsumed tests if the Consumed calls inside a transaction are deferred until the outermost transaction
+// is committed.
+func TestTxDefersConsumed(t *testing.T) {
+	f := NewFileFromReader(bufio.NewReader(strings.NewReader("abcdefgh")), 2, 1<<10, t.TempDir()).(*reader)
+	defer f.Close()
+
+	outer := f.Begin()
+	f.ReadBytes(4)
+	inner := f.Begin()
+	f.ReadBytes(2)
+	f.Consumed(6)
+	if f.s.startOffset != 0 {
+		t.Errorf("expected startOffset = 0, got %d", f.s.startOffset)
+	}
+	inner.Rollback()
+	f.Consumed(3)
+	inner = f.Begin()
+	f.ReadBytes(2)
+	f.Consumed(5)
+	inner.Commit()
+	if f.s.startOffset != 0 {
+		t.Errorf("expected startOffset = 0, got %d", f.s.startOffset)
+	}
+	outer.Rollback()
+	if f.Offset() != 0 {
+		t.Errorf("expected offset = 0, got %d", f.Offset())
+	}
+	if f.s.startOffset != 0 {
+		t.Errorf("expected startOffset = 0, got %d", f.s.startOffset)
+	}
+
+	tx := f.Begin()
+	f.ReadBytes(6)
+	f.Consumed(5)
+	tx.Commit()
+	if f.s.startOffset != 4 {
+		t.Errorf("expected startOffset = 4, got %d", f.s.startOffset)
+	}
+}
+
+// TestPanicTxClosed tests if a closed transaction panics when it is closed again.
+func TestPanicTxClosed(t *testing.T) {
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Errorf("panic expected")
+			return
+		}
+		if msg := err.(error).Error(); msg != "transaction is closed" {
+			t.Errorf("expected error message %q, got %q", "transaction is closed", msg)
+		}
+	}()
+
+	f := NewFile([]byte("test"))
+	outer := f.Begin()
+	inner := f.Begin()
+	outer.Commit()
+	inner.Rollback()
+}
@@ -0,0 +1,163 @@
+package rem
+
+import (
+	"strconv"
+	"unicode/utf16"
+)
+
+// UnescapedFile is a File that reads the Java-style Unicode escapes of another File, the source, decoded. An escape is
+// a backslash that is preceded by an even number of backslashes, followed by one or more 'u' and four hexadecimal
+// digits. Surrogate pairs must be written as two consecutive escapes. The offsets count the bytes after the decoding,
+// SourceOffset maps them to the offsets of the source. The methods panic with an *EscapeError on malformed escapes.
+type UnescapedFile interface {
+	File
+
+	// SourceOffset returns the offset in the source that corresponds to offset. offset must be an offset already read.
+	SourceOffset(offset int64) int64
+}
+
+// EscapeError is a malformed Unicode escape.
+type EscapeError struct {
+	// Offset is the offset of the escape in the source.
+	Offset int64
+	// Msg describes the error.
+	Msg string
+}
+
+// Error returns the message of the error with the offset of the escape.
+func (e *EscapeError) Error() string {
+	return "malformed Unicode escape at offset " + strconv.FormatInt(e.Offset, 10) + ": " + e.Msg
+}
+
+// unescapedFile is an UnescapedFile.
+type unescapedFile struct {
+	*view
+}
+
+// NewUnescapedFile returns an UnescapedFile that reads f from its current offset. The offsets start at the current
+// offset of f. Close closes f.
+func NewUnescapedFile(f File) UnescapedFile {
+	return &unescapedFile{view: newView(f, escapeCodec{}, false)}
+}
+
+// Fork returns a new UnescapedFile with an independent offset initially equal to the current offset of uf.
+func (uf *unescapedFile) Fork() File {
+	return &unescapedFile{view: uf.view.fork()}
+}
+
+// escapeCodec is the codec of an UnescapedFile. A unit is an escape, a surrogate pair of escapes, or a rune.
+type escapeCodec struct{}
+
+// next decodes the unit at the current offset of src.
+func (escapeCodec) next(src File, fresh bool) (r rune, eof bool) {
+	start := src.Offset()
+	u, ok := escapeAt(src)
+	if !ok {
+		return src.Next()
+	}
+	if utf16.IsSurrogate(u) {
+		low, ok := escapeAt(src)
+		if u >= 0xdc00 || !ok || low < 0xdc00 || low > 0xdfff {
+			panic(&EscapeError{Offset: start, Msg: "unpaired surrogate"})
+		}
+		u = utf16.DecodeRune(u, low)
+	}
+	return u, false
+}
+
+// previous decodes the unit before the current offset of src.
+func (escapeCodec) previous(src File) (r rune, onStart bool) {
+	u, ok := escapeBefore(src)
+	if !ok {
+		return src.Previous()
+	}
+	if utf16.IsSurrogate(u) {
+		start := src.Offset()
+		high, ok := escapeBefore(src)
+		if u < 0xdc00 || !ok || high < 0xd800 || high >= 0xdc00 {
+			panic(&EscapeError{Offset: start, Msg: "unpaired surrogate"})
+		}
+		u = utf16.DecodeRune(high, u)
+	}
+	return u, false
+}
+
+// clone returns the codec, because it has no state.
+func (ec escapeCodec) clone() codec {
+	return ec
+}
+
+// escapeAt decodes the escape at the current offset of src and put the offset after it. If there is no escape, the
+// offset remains unchanged and ok is false. It panics if the escape is malformed.
+func escapeAt(src File) (u rune, ok bool) {
+	start := src.Offset()
+	if b, eof := src.NextByte(); eof || b != '\\' {
+		moveFile(src, start)
+		return 0, false
+	}
+	us := 0
+	for b, eof := src.NextByte(); !eof; b, eof = src.NextByte() {
+		if b != 'u' {
+			src.PreviousByte()
+			break
+		}
+		us++
+	}
+	if us == 0 || !evenBackslashesBefore(src, start) {
+		moveFile(src, start)
+		return 0, false
+	}
+	p, _ := src.ReadBytes(4)
+	v, err := strconv.ParseUint(string(p), 16, 16)
+	if len(p) < 4 || err != nil {
+		panic(&EscapeError{Offset: start, Msg: "invalid hexadecimal digits " + strconv.Quote(string(p))})
+	}
+	return rune(v), true
+}
+
+// escapeBefore decodes the escape before the current offset of src and put the offset at its start. If there is no
+// escape, the offset remains unchanged and ok is false.
+func escapeBefore(src File) (u rune, ok bool) {
+	end := src.Offset()
+	digits := make([]byte, 4)
+	for i := len(digits) - 1; i >= 0; i-- {
+		b, onStart := src.PreviousByte()
+		if onStart || !isHexDigit(b) {
+			moveFile(src, end)
+			return 0, false
+		}
+		digits[i] = b
+	}
+	us := 0
+	for b, onStart := src.PreviousByte(); !onStart; b, onStart = src.PreviousByte() {
+		if b != 'u' {
+			src.NextByte()
+			break
+		}
+		us++
+	}
+	if b, onStart := src.PreviousByte(); us == 0 || onStart || b != '\\' || !evenBackslashesBefore(src, src.Offset()) {
+		moveFile(src, end)
+		return 0, false
+	}
+	v, _ := strconv.ParseUint(string(digits), 16, 16)
+	return rune(v), true
+}
+
+// evenBackslashesBefore reports whether the number of consecutive backslashes before offset in src is even. The offset
+// of src remains unchanged.
+func evenBackslashesBefore(src File, offset int64) bool {
+	cur := src.Offset()
+	defer moveFile(src, cur)
+	moveFile(src, offset)
+	n := 0
+	for b, onStart := src.PreviousByte(); !onStart && b == '\\'; b, onStart = src.PreviousByte() {
+		n++
+	}
+	return n%2 == 0
+}
+
+// isHexDigit reports whether b is a hexadecimal digit.
+func isHexDigit(b byte) bool {
+	return '0' <= b && b <= '9' || 'a' <= b && b <= 'f' || 'A' <= b && b <= 'F'
+}
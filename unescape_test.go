@@ -0,0 +1,104 @@
+package rem
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestUnescapedFile tests the decoding in both directions and the mapping of the offsets.
+func TestUnescapedFile(t *testing.T) {
+	const data = `ab\uD83D\uDE00\\u0041\uuu00e7\`
+	uf := NewUnescapedFile(NewFileFromString(data))
+	defer uf.Close()
+
+	const want = "ab😀\\\\u0041ç\\"
+	if got := readString(uf); got != want {
+		t.Errorf("unexpected content %q", got)
+	}
+	sources := map[int64]int64{0: 0, 1: 1, 2: 2, 6: 14, 7: 15, 8: 16, 13: 21, 15: 29, 16: 30}
+	for offset, source := range sources {
+		if got := uf.SourceOffset(offset); got != source {
+			t.Errorf("expected that %d maps to %d, got %d", offset, source, got)
+		}
+	}
+
+	runes := []rune(want)
+	for i := len(runes) - 1; i >= 0; i-- {
+		if r, onStart := uf.Previous(); onStart || r != runes[i] {
+			t.Fatalf("expected %q at %d, got %q", runes[i], i, r)
+		}
+	}
+	if _, onStart := uf.Previous(); !onStart {
+		t.Errorf("expected the start")
+	}
+
+	if i := uf.IndexRune('😀'); i != 2 {
+		t.Errorf("unexpected index %d", i)
+	}
+	if p, _ := uf.ReadBytes(4); string(p) != "ab\xf0\x9f" {
+		t.Errorf("unexpected bytes %q", p)
+	}
+	if b, _ := uf.PreviousByte(); b != 0x9f {
+		t.Errorf("expected 0x9f, got %#x", b)
+	}
+	tx := uf.Begin()
+	if !uf.SkipUntil("ç") || uf.Offset() != 13 {
+		t.Errorf("unexpected offset %d", uf.Offset())
+	}
+	tx.Rollback()
+	if offset := uf.Offset(); offset != 3 {
+		t.Errorf("unexpected offset %d", offset)
+	}
+	if i := uf.LastIndexString("ab"); i != 0 {
+		t.Errorf("unexpected index %d", i)
+	}
+}
+
+// TestUnescapedFileErrors tests the malformed escapes.
+func TestUnescapedFileErrors(t *testing.T) {
+	tests := []struct {
+		data   string
+		offset int64
+	}{
+		{`ab\u12`, 2},
+		{`ab\u12G4`, 2},
+		{`a\uD83Dx`, 1},
+		{`a\uDE00`, 1},
+		{`a\uD83DA`, 1},
+	}
+	for _, test := range tests {
+		func() {
+			defer func() {
+				var e *EscapeError
+				if err, _ := recover().(error); !errors.As(err, &e) || e.Offset != test.offset {
+					t.Errorf("%q: expected an *EscapeError at %d, got %v", test.data, test.offset, err)
+				}
+			}()
+			readString(NewUnescapedFile(NewFileFromString(test.data)))
+		}()
+	}
+	if msg := (&EscapeError{Offset: 3, Msg: "unpaired surrogate"}).Error(); msg != "malformed Unicode escape at offset 3: unpaired surrogate" {
+		t.Errorf("unexpected message %q", msg)
+	}
+}
+
+// TestUnescapedFileStream tests the consumption and the forks on a stream.
+func TestUnescapedFileStream(t *testing.T) {
+	uf := NewUnescapedFile(NewFileFromReader(strings.NewReader(`a\u0062cde`), 8, 0, ""))
+	defer uf.Close()
+	uf.ReadBytes(2)
+	fork := uf.Fork()
+	defer fork.Close()
+	uf.Consumed(2)
+	if got := readString(uf); got != "cde" {
+		t.Errorf("unexpected content %q", got)
+	}
+	uf.Consumed(5)
+	if got := readString(fork); got != "cde" {
+		t.Errorf("unexpected content %q", got)
+	}
+	if offset := fork.(UnescapedFile).SourceOffset(fork.Offset()); offset != 10 {
+		t.Errorf("unexpected offset %d", offset)
+	}
+}
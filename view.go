@@ -0,0 +1,295 @@
+package rem
+
+import (
+	"errors"
+	"sort"
+	"unicode/utf8"
+)
+
+// codec decodes the runes of a view from its source. Each rune is decoded from a unit of the source, and next and
+// previous always start and end at the boundaries of the units.
+type codec interface {
+	// next decodes the unit at the current offset of src and put the offset of src after it. fresh reports whether the
+	// unit was not read before. It panics on error.
+	next(src File, fresh bool) (r rune, eof bool)
+	// previous decodes the unit before the current offset of src and put the offset of src at its start. It panics on
+	// error.
+	previous(src File) (r rune, onStart bool)
+	// clone returns a copy of the codec for a fork.
+	clone() codec
+}
+
+// mark is a point where the difference between the view offsets and the source offsets changes.
+type mark struct {
+	// offset is the view offset.
+	offset int64
+	// source is the source offset.
+	source int64
+}
+
+// view is a File that reads the runes decoded by a codec from another File, the source. The bytes of the view are the
+// UTF-8 encodings of the decoded runes.
+type view struct {
+	// src is the source.
+	src File
+	// c is the codec.
+	c codec
+	// start is the offset of the start of the view in the source.
+	start int64
+	// sourceOffsets reports whether the offsets of the view are the offsets of the source. If it is false, the offsets
+	// count the bytes of the view.
+	sourceOffsets bool
+	// offset is the current offset of the view, if sourceOffsets is false.
+	offset int64
+	// high is the farthest offset of the view read.
+	high int64
+	// marks map the offsets of the view read to the offsets of the source, if sourceOffsets is false.
+	marks []mark
+	// part is the encoding of the rune at the current offset, if the offset is in the middle of the rune. Then the
+	// offset of the source is after its unit. If sourceOffsets is true, the encoding must be at the start of the
+	// unit.
+	part []byte
+	// pos is the index in part of the byte at the current offset.
+	pos int
+	// partStart is the offset in the source of the start of the unit of part.
+	partStart int64
+	// tx are the open transactions.
+	tx transactions
+}
+
+// newView creates a new view that reads src from its current offset. The offsets start at the current offset of src.
+func newView(src File, c codec, sourceOffsets bool) *view {
+	start := src.Offset()
+	v := &view{src: src, c: c, start: start, sourceOffsets: sourceOffsets, offset: start, high: start}
+	if !sourceOffsets {
+		v.marks = []mark{{start, start}}
+	}
+	return v
+}
+
+// SourceOffset returns the offset in the source that corresponds to offset. offset must be an offset read.
+func (v *view) SourceOffset(offset int64) int64 {
+	if v.sourceOffsets {
+		return offset
+	}
+	i := sort.Search(len(v.marks), func(i int) bool { return v.marks[i].offset > offset }) - 1
+	if i < 0 {
+		i = 0
+	}
+	return v.marks[i].source + offset - v.marks[i].offset
+}
+
+// decodeNext decodes the next unit and updates the offsets.
+func (v *view) decodeNext() (r rune, eof bool) {
+	fresh := v.Offset() >= v.high
+	srcStart := v.src.Offset()
+	if r, eof = v.c.next(v.src, fresh); eof {
+		return r, eof
+	}
+	n := int64(utf8.RuneLen(r))
+	v.offset += n
+	if fresh {
+		if !v.sourceOffsets && v.src.Offset()-srcStart != n {
+			v.marks = append(v.marks, mark{v.offset, v.src.Offset()})
+		}
+		v.high = v.Offset()
+	}
+	return r, false
+}
+
+// decodePrevious decodes the previous unit and updates the offsets.
+func (v *view) decodePrevious() (r rune, onStart bool) {
+	if v.src.Offset() <= v.start {
+		return 0, true
+	}
+	if r, onStart = v.c.previous(v.src); !onStart {
+		v.offset -= int64(utf8.RuneLen(r))
+	}
+	return r, onStart
+}
+
+// Next returns the rune at the current offset. It panics if the current offset is in the middle of a rune.
+func (v *view) Next() (r rune, eof bool) {
+	if v.part != nil {
+		panic(errors.New("invalid UTF-8 encoding"))
+	}
+	return v.decodeNext()
+}
+
+// Previous returns the rune before the current offset. It panics if the current offset is in the middle of a rune.
+func (v *view) Previous() (r rune, onStart bool) {
+	if v.part != nil {
+		panic(errors.New("invalid UTF-8 encoding"))
+	}
+	return v.decodePrevious()
+}
+
+// NextByte returns the byte at the current offset.
+func (v *view) NextByte() (b byte, eof bool) {
+	if v.part == nil {
+		srcStart := v.src.Offset()
+		r, eof := v.decodeNext()
+		if eof {
+			return 0, true
+		}
+		p := utf8.AppendRune(nil, r)
+		if len(p) == 1 {
+			return p[0], false
+		}
+		v.part, v.pos, v.partStart = p, 0, srcStart
+		v.offset -= int64(len(p))
+	}
+	b = v.part[v.pos]
+	v.pos++
+	v.offset++
+	if v.pos == len(v.part) {
+		v.part = nil
+	}
+	return b, false
+}
+
+// PreviousByte returns the byte before the current offset.
+func (v *view) PreviousByte() (b byte, onStart bool) {
+	if v.part == nil {
+		srcEnd := v.src.Offset()
+		r, onStart := v.decodePrevious()
+		if onStart {
+			return 0, true
+		}
+		p := utf8.AppendRune(nil, r)
+		if len(p) == 1 {
+			return p[0], false
+		}
+		v.part, v.pos, v.partStart = p, len(p), v.src.Offset()
+		v.offset += int64(len(p))
+		moveFile(v.src, srcEnd)
+	}
+	v.pos--
+	v.offset--
+	b = v.part[v.pos]
+	if v.pos == 0 {
+		v.part = nil
+		moveFile(v.src, v.partStart)
+	}
+	return b, false
+}
+
+// ReadBytes returns the next n bytes.
+func (v *view) ReadBytes(n int) (p []byte, eof bool) {
+	for len(p) < n {
+		b, eof := v.NextByte()
+		if eof {
+			return p, true
+		}
+		p = append(p, b)
+	}
+	return p, false
+}
+
+// IndexRune returns the offset of the first instance of r at or after the current offset, or -1 if r is not present.
+func (v *view) IndexRune(r rune) int64 {
+	return indexFile(v, utf8.AppendRune(nil, r))
+}
+
+// IndexString returns the offset of the first instance of s at or after the current offset, or -1 if s is not present.
+func (v *view) IndexString(s string) int64 {
+	return indexFile(v, []byte(s))
+}
+
+// LastIndexRune returns the offset of the last instance of r that ends at or before the current offset, or -1 if r is
+// not present.
+func (v *view) LastIndexRune(r rune) int64 {
+	return lastIndexFile(v, utf8.AppendRune(nil, r))
+}
+
+// LastIndexString returns the offset of the last instance of s that ends at or before the current offset, or -1 if s
+// is not present.
+func (v *view) LastIndexString(s string) int64 {
+	return lastIndexFile(v, []byte(s))
+}
+
+// SkipUntil put the offset at the start of the first instance of delim at or after the current offset.
+func (v *view) SkipUntil(delim string) (found bool) {
+	i := v.IndexString(delim)
+	if i < 0 {
+		for _, eof := v.NextByte(); !eof; _, eof = v.NextByte() {
+		}
+		return false
+	}
+	v.setOffset(i)
+	return true
+}
+
+// Consumed marks the bytes before offset as consumed.
+func (v *view) Consumed(offset int64) {
+	if offset > v.Offset() {
+		panic(errors.New("invalid offset"))
+	}
+	if !v.tx.deferConsumed(offset) {
+		v.consume(offset)
+	}
+}
+
+// consume marks the bytes of the source before the offset that corresponds to offset as consumed, and removes the
+// marks that are not needed anymore.
+func (v *view) consume(offset int64) {
+	v.src.Consumed(min(v.SourceOffset(offset), v.src.Offset()))
+	if i := sort.Search(len(v.marks), func(i int) bool { return v.marks[i].offset > offset }) - 1; i > 0 {
+		v.marks = append(v.marks[:0], v.marks[i:]...)
+	}
+}
+
+// Begin begins a transaction at the current offset. Transactions can be nested.
+func (v *view) Begin() Tx {
+	return v.tx.begin(v)
+}
+
+// setOffset put the offset at offset.
+func (v *view) setOffset(offset int64) {
+	for v.Offset() > offset {
+		if _, onStart := v.PreviousByte(); onStart {
+			return
+		}
+	}
+	for v.Offset() < offset {
+		if _, eof := v.NextByte(); eof {
+			return
+		}
+	}
+}
+
+// fork returns a new view with a fork of the source and a copy of the codec, with an independent offset initially
+// equal to the current offset of v.
+func (v *view) fork() *view {
+	fork := *v
+	fork.src = v.src.Fork()
+	fork.c = v.c.clone()
+	fork.marks = append([]mark(nil), v.marks...)
+	fork.part = append([]byte(nil), v.part...)
+	if v.part == nil {
+		fork.part = nil
+	}
+	fork.tx = transactions{}
+	return &fork
+}
+
+// Fork returns a new view with an independent offset initially equal to the current offset of v.
+func (v *view) Fork() File {
+	return v.fork()
+}
+
+// Offset returns the current offset.
+func (v *view) Offset() int64 {
+	if !v.sourceOffsets {
+		return v.offset
+	}
+	if v.part != nil {
+		return v.partStart + int64(v.pos)
+	}
+	return v.src.Offset()
+}
+
+// Close closes the source.
+func (v *view) Close() error {
+	return v.src.Close()
+}